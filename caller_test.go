@@ -0,0 +1,67 @@
+package loglet_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/duhaifeng/loglet"
+	"github.com/duhaifeng/loglet/logtest"
+)
+
+/**
+ * 日志记录点需要在包外调用时才能正确识别，因此这些测试使用外部测试包
+ */
+func TestDefaultLoggerCaller(t *testing.T) {
+	origin := loglet.Default()
+	defer loglet.SetDefault(origin)
+	logger := loglet.NewLogger()
+	logger.CloseWriters()
+	recorder := logtest.NewRecorder()
+	logger.RegisterWriter("recorder", recorder)
+	loglet.SetDefault(logger)
+
+	loglet.Info("hello %s", "default")
+	loglet.Error(errors.New("boom"))
+	assertCaller(t, recorder, 2, "caller_test.go", "TestDefaultLoggerCaller")
+}
+
+func TestStdLoggerCaller(t *testing.T) {
+	logger := loglet.NewLogger()
+	logger.CloseWriters()
+	recorder := logtest.NewRecorder()
+	logger.RegisterWriter("recorder", recorder)
+
+	logger.StdLogger("warn").Printf("disk usage %d%%", 90)
+	fmt.Fprint(logger.Writer("error"), "line\n")
+	assertCaller(t, recorder, 2, "caller_test.go", "TestStdLoggerCaller")
+}
+
+func TestCallerPackageRoute(t *testing.T) {
+	logger := loglet.NewLogger()
+	logger.CloseWriters()
+	recorder, other := logtest.NewRecorder(), logtest.NewRecorder()
+	logger.RegisterWriter("recorder", recorder)
+	logger.RegisterWriter("other", other)
+	logger.AddRoute(loglet.Route{CallerPackage: "github.com/duhaifeng/loglet_test", Writers: []string{"recorder"}})
+	logger.AddRoute(loglet.Route{Writers: []string{"other"}})
+
+	logger.Info("from test")
+	if len(recorder.Messages()) != 1 || len(other.Messages()) != 0 {
+		t.Errorf("caller package route should match: %d, %d", len(recorder.Messages()), len(other.Messages()))
+	}
+}
+
+func assertCaller(t *testing.T, recorder *logtest.Recorder, count int, file string, function string) {
+	t.Helper()
+	msgs := recorder.Messages()
+	if len(msgs) != count {
+		t.Fatalf("expected %d messages, got %d", count, len(msgs))
+	}
+	for _, msg := range msgs {
+		if !strings.HasPrefix(msg.TargetPoint(), file+" ") || !strings.Contains(msg.TargetPoint(), function) {
+			t.Errorf("logging point should be the caller, got: %s", msg.TargetPoint())
+		}
+	}
+}
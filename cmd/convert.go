@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/duhaifeng/loglet"
+)
+
+/**
+ * loglet convert：在文本和JSON格式之间转换日志，用于迁移历史日志
+ * 未指定文件时从标准输入读取
+ */
+func runConvert(args []string) error {
+	flags := flag.NewFlagSet("convert", flag.ContinueOnError)
+	to := flags.String("to", "json", "target format (json, text)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: loglet convert [options] [file ...]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	var formatter loglet.Formatter
+	switch *to {
+	case "json":
+		formatter = new(loglet.JSONFormatter)
+	case "text":
+		formatter = new(loglet.TextFormatter)
+	default:
+		return fmt.Errorf("unsupported target format: %s", *to)
+	}
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+	if flags.NArg() == 0 {
+		return convertLogs(os.Stdin, out, formatter)
+	}
+	for _, fileName := range flags.Args() {
+		file, err := os.Open(fileName)
+		if err != nil {
+			return err
+		}
+		err = convertLogs(file, out, formatter)
+		file.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/**
+ * 逐条读取日志并按指定格式输出
+ */
+func convertLogs(in io.Reader, out io.Writer, formatter loglet.Formatter) error {
+	reader := loglet.NewReader(in)
+	for {
+		msg, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if _, err = out.Write(formatter.Format(msg)); err != nil {
+			return err
+		}
+	}
+}
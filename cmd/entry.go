@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"github.com/duhaifeng/loglet"
+)
+
+/**
+ * 一条日志条目，包含行头所在的行以及多行日志的后续行
+ */
+type logEntry struct {
+	msg   *loglet.LogMsg //不以行头开始的孤立行为nil
+	lines []string
+}
+
+/**
+ * 判断条目中任意一行是否满足匹配函数
+ */
+func (entry *logEntry) matchAny(match func(line string) bool) bool {
+	for _, line := range entry.lines {
+		if match(line) {
+			return true
+		}
+	}
+	return false
+}
+
+/**
+ * 日志条目扫描器，将按行读取的内容组合为日志条目
+ */
+type entryScanner struct {
+	scanner *bufio.Scanner
+	next    *logEntry //已读取了行头、尚未返回的条目
+	entry   *logEntry
+}
+
+/**
+ * 创建一个日志条目扫描器
+ */
+func newEntryScanner(reader io.Reader) *entryScanner {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	return &entryScanner{scanner: scanner}
+}
+
+/**
+ * 读取下一条日志条目，没有更多条目时返回false
+ */
+func (scanner *entryScanner) Scan() bool {
+	scanner.entry = nil
+	for scanner.scanner.Scan() {
+		line := strings.TrimSuffix(scanner.scanner.Text(), "\r")
+		msg, err := loglet.ParseLine([]byte(line))
+		if err != nil && scanner.next != nil {
+			scanner.next.lines = append(scanner.next.lines, line)
+			continue
+		}
+		scanner.entry = scanner.next
+		scanner.next = &logEntry{msg: msg, lines: []string{line}}
+		if scanner.entry != nil {
+			return true
+		}
+	}
+	scanner.entry, scanner.next = scanner.next, nil
+	return scanner.entry != nil
+}
+
+/**
+ * 获取当前读取到的日志条目
+ */
+func (scanner *entryScanner) Entry() *logEntry {
+	return scanner.entry
+}
+
+/**
+ * 获取扫描过程中的错误
+ */
+func (scanner *entryScanner) Err() error {
+	return scanner.scanner.Err()
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/duhaifeng/loglet"
+)
+
+/**
+ * 日志级别对应的数字，用于按最低级别过滤
+ */
+var levelNums = map[string]int{
+	loglet.DEBUG: loglet.DEBUG_LEVEL,
+	loglet.INFO:  loglet.INFO_LEVEL,
+	loglet.WARN:  loglet.WARN_LEVEL,
+	loglet.ERROR: loglet.ERROR_LEVEL,
+	loglet.FATAL: loglet.FATAL_LEVEL,
+}
+
+/**
+ * 命令行中可接受的时间格式（均按本地时区解析）
+ */
+var timeLayouts = []string{
+	"2006-01-02 15:04:05.000",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05Z07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+/**
+ * 日志条目过滤条件
+ */
+type entryFilter struct {
+	minLevel int
+	caller   string
+	since    time.Time
+	until    time.Time
+}
+
+/**
+ * 根据命令行参数创建过滤条件
+ */
+func newEntryFilter(level, caller, since, until string) (*entryFilter, error) {
+	filter := &entryFilter{caller: caller}
+	if level != "" {
+		levelNum, ok := levelNums[strings.ToUpper(level)]
+		if !ok {
+			return nil, fmt.Errorf("unknown log level: %s", level)
+		}
+		filter.minLevel = levelNum
+	}
+	var err error
+	if filter.since, err = parseTimeArg(since); err != nil {
+		return nil, err
+	}
+	if filter.until, err = parseTimeArg(until); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+/**
+ * 判断是否未设置任何过滤条件
+ */
+func (filter *entryFilter) empty() bool {
+	return filter.minLevel <= 0 && filter.caller == "" && filter.since.IsZero() && filter.until.IsZero()
+}
+
+/**
+ * 判断日志是否满足过滤条件
+ */
+func (filter *entryFilter) match(msg *loglet.LogMsg) bool {
+	if levelNums[msg.Level()] < filter.minLevel {
+		return false
+	}
+	if filter.caller != "" && !strings.Contains(msg.TargetPoint(), filter.caller) {
+		return false
+	}
+	if !filter.since.IsZero() && msg.Time().Before(filter.since) {
+		return false
+	}
+	if !filter.until.IsZero() && msg.Time().After(filter.until) {
+		return false
+	}
+	return true
+}
+
+/**
+ * 解析命令行中的时间参数，为空时返回零值
+ */
+func parseTimeArg(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range timeLayouts {
+		parsedTime, err := time.ParseInLocation(layout, value, time.Local)
+		if err == nil {
+			return parsedTime, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("can not parse time: %s", value)
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+
+	"github.com/duhaifeng/loglet"
+)
+
+/**
+ * loglet grep：将日志文件的所有分段（包括压缩的历史文件）按时间顺序作为一个连续的流进行检索
+ */
+func runGrep(args []string) error {
+	flags := flag.NewFlagSet("grep", flag.ContinueOnError)
+	from := flags.String("from", "", "only search entries logged at or after this time")
+	to := flags.String("to", "", "only search entries logged at or before this time")
+	level := flags.String("level", "", "only search entries at or above this level (DEBUG, INFO, WARN, ERROR, FATAL)")
+	ignoreCase := flags.Bool("i", false, "case insensitive match")
+	after := flags.Int("A", 0, "print N entries of trailing context")
+	before := flags.Int("B", 0, "print N entries of leading context")
+	contextLines := flags.Int("C", 0, "print N entries of leading and trailing context")
+	naming := flags.String("naming", loglet.NAMING_TIMESTAMP, "naming scheme of rotated segments (timestamp, numbered, date)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: loglet grep [options] PATTERN <base log file>")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return errors.New("a pattern and a base log file are required")
+	}
+	pattern := flags.Arg(0)
+	if *ignoreCase {
+		pattern = "(?i)" + pattern
+	}
+	regex, err := regexp.Compile(pattern)
+	if err != nil {
+		return err
+	}
+	filter, err := newEntryFilter(*level, "", *from, *to)
+	if err != nil {
+		return err
+	}
+	if *contextLines > 0 {
+		*after, *before = *contextLines, *contextLines
+	}
+	namingScheme, err := loglet.NamingSchemeByName(*naming)
+	if err != nil {
+		return err
+	}
+	segments, err := namingScheme.Segments(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+	if len(segments) == 0 {
+		return fmt.Errorf("no log file found for %s", flags.Arg(1))
+	}
+	searcher := &entrySearcher{regex: regex, filter: filter, before: *before, after: *after, out: os.Stdout}
+	for _, segment := range segments {
+		if err = searcher.searchSegment(segment); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/**
+ * 日志条目检索器，支持前后文输出（以日志条目为单位，多行日志作为整体）
+ */
+type entrySearcher struct {
+	regex        *regexp.Regexp
+	filter       *entryFilter
+	before       int
+	after        int
+	out          io.Writer
+	leading      []*logEntry //最近的若干条未输出的条目，用于输出前文
+	trailingLeft int         //还需输出的后文条目数
+	printedAny   bool        //是否已经输出过内容
+	skipped      int         //自上次输出以来跳过的条目数，用于判断是否需要输出分隔符
+}
+
+/**
+ * 检索一个日志分段
+ */
+func (searcher *entrySearcher) searchSegment(segment loglet.LogSegment) error {
+	reader, err := loglet.OpenLogSegment(segment)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	scanner := newEntryScanner(reader)
+	for scanner.Scan() {
+		searcher.searchEntry(scanner.Entry())
+	}
+	return scanner.Err()
+}
+
+/**
+ * 检索一条日志条目，匹配时连同前后文一起输出
+ */
+func (searcher *entrySearcher) searchEntry(entry *logEntry) {
+	//不满足时间、级别条件的条目既不匹配也不作为前后文
+	if entry.msg != nil && !searcher.filter.match(entry.msg) {
+		return
+	}
+	if entry.msg == nil && !searcher.filter.empty() {
+		return
+	}
+	if entry.matchAny(searcher.regex.MatchString) {
+		if searcher.printedAny && searcher.skipped > len(searcher.leading) && (searcher.before > 0 || searcher.after > 0) {
+			fmt.Fprintln(searcher.out, "--")
+		}
+		for _, leadingEntry := range searcher.leading {
+			searcher.printEntry(leadingEntry)
+		}
+		searcher.leading = searcher.leading[:0]
+		searcher.printEntry(entry)
+		searcher.trailingLeft = searcher.after
+		return
+	}
+	if searcher.trailingLeft > 0 {
+		searcher.trailingLeft--
+		searcher.printEntry(entry)
+		return
+	}
+	searcher.skipped++
+	if searcher.before > 0 {
+		if len(searcher.leading) == searcher.before {
+			searcher.leading = searcher.leading[1:]
+		}
+		searcher.leading = append(searcher.leading, entry)
+	}
+}
+
+/**
+ * 输出一条日志条目
+ */
+func (searcher *entrySearcher) printEntry(entry *logEntry) {
+	for _, line := range entry.lines {
+		fmt.Fprintln(searcher.out, line)
+	}
+	searcher.printedAny = true
+	searcher.skipped = 0
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/duhaifeng/loglet"
+)
+
+func TestGrepSegments(t *testing.T) {
+	logDir := t.TempDir()
+	baseFile := filepath.Join(logDir, "app.log")
+	var gzipBuf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&gzipBuf)
+	gzipWriter.Write([]byte("2024-01-01 10:00:00.000 a.go 1 main.main() [1] [INFO] first request\n"))
+	gzipWriter.Close()
+	files := map[string]string{
+		"app.20240101_100001.000.log.gz": gzipBuf.String(),
+		"app.20240101_100003.000.log": "2024-01-01 10:00:02.000 a.go 1 main.main() [1] [INFO] noise\n" +
+			"2024-01-01 10:00:02.500 a.go 1 main.main() [1] [ERROR] second request failed\ntrace line\n",
+		"app.log":   "2024-01-01 10:00:04.000 a.go 1 main.main() [1] [INFO] third request\n",
+		"other.log": "2024-01-01 10:00:00.000 a.go 1 main.main() [1] [INFO] other request\n",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(logDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	segments, err := loglet.FindLogSegments(baseFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(segments) != 3 || !segments[0].Compressed || !segments[2].Active {
+		t.Fatalf("unexpected segments: %+v", segments)
+	}
+
+	filter, _ := newEntryFilter("", "", "2024-01-01 10:00:00.500", "")
+	var out bytes.Buffer
+	searcher := &entrySearcher{regex: regexp.MustCompile("request"), filter: filter, out: &out}
+	for _, segment := range segments {
+		if err = searcher.searchSegment(segment); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected := "2024-01-01 10:00:02.500 a.go 1 main.main() [1] [ERROR] second request failed\ntrace line\n" +
+		"2024-01-01 10:00:04.000 a.go 1 main.main() [1] [INFO] third request\n"
+	if out.String() != expected {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+
+	filter, _ = newEntryFilter("", "", "", "")
+	out.Reset()
+	searcher = &entrySearcher{regex: regexp.MustCompile("failed|third"), filter: filter, before: 1, out: &out}
+	for _, segment := range segments {
+		searcher.searchSegment(segment)
+	}
+	expected = "2024-01-01 10:00:02.000 a.go 1 main.main() [1] [INFO] noise\n" + expected
+	if out.String() != expected {
+		t.Errorf("unexpected context output:\n%s", out.String())
+	}
+}
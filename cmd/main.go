@@ -1,10 +1,51 @@
 package main
 
 import (
-	"github.com/duhaifeng/loglet"
+	"fmt"
+	"os"
 )
 
+/**
+ * loglet命令行工具，用于查看和分析loglet输出的日志文件
+ */
+const usage = `usage: loglet <command> [options]
+
+commands:
+  tail    follow a loglet log file across rotation
+  grep    search all rotated segments of a log file in time order
+  convert convert logs between text and json format
+  stats   summarize levels, callers, message templates and errors
+  help    show this help
+`
+
+/**
+ * 命令行子命令定义
+ */
+var commands = map[string]func(args []string) error{
+	"tail":    runTail,
+	"grep":    runGrep,
+	"convert": runConvert,
+	"stats":   runStats,
+}
+
 func main() {
-	logger := loglet.NewLogger()
-	logger.Debug("aaaaaaaaaaaaaaaa")
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	commandName := os.Args[1]
+	if commandName == "help" || commandName == "-h" || commandName == "--help" {
+		fmt.Print(usage)
+		return
+	}
+	command, ok := commands[commandName]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "loglet: unknown command: %s\n\n%s", commandName, usage)
+		os.Exit(2)
+	}
+	err := command(os.Args[2:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "loglet %s: %s\n", commandName, err.Error())
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/duhaifeng/loglet"
+)
+
+/**
+ * 消息模板归一化规则：将消息中的可变部分替换为占位符，使同一处日志的不同输出归为一类
+ */
+var templateRules = []struct {
+	regex       *regexp.Regexp
+	placeholder string
+}{
+	{regexp.MustCompile(`"[^"]*"|'[^']*'`), "<str>"},
+	{regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`), "<uuid>"},
+	{regexp.MustCompile(`\b0x[0-9a-fA-F]+\b`), "<hex>"},
+	{regexp.MustCompile(`\b\d+(\.\d+)*\b`), "<num>"},
+}
+
+/**
+ * 代码点末尾的goroutine号：file line func() [goroutine]
+ */
+var routineRegex = regexp.MustCompile(` \[(\d+)\]$`)
+
+/**
+ * loglet stats：统计日志文件中各级别数量、最频繁的代码点与消息模板、每分钟的错误数以及最繁忙的goroutine
+ */
+func runStats(args []string) error {
+	flags := flag.NewFlagSet("stats", flag.ContinueOnError)
+	top := flags.Int("top", 10, "number of entries in each top list")
+	format := flags.String("format", "table", "output format (table, json)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: loglet stats [options] [file ...]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *format != "table" && *format != "json" {
+		return fmt.Errorf("unsupported output format: %s", *format)
+	}
+	collector := newStatsCollector()
+	if flags.NArg() == 0 {
+		if err := collector.collect(os.Stdin); err != nil {
+			return err
+		}
+	}
+	for _, fileName := range flags.Args() {
+		segment := loglet.LogSegment{Path: fileName, Compressed: strings.HasSuffix(fileName, ".gz")}
+		reader, err := loglet.OpenLogSegment(segment)
+		if err != nil {
+			return err
+		}
+		err = collector.collect(reader)
+		reader.Close()
+		if err != nil {
+			return err
+		}
+	}
+	report := collector.report(*top)
+	if *format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+	report.printTable(os.Stdout)
+	return nil
+}
+
+/**
+ * 日志统计收集器
+ */
+type statsCollector struct {
+	total        int
+	levels       map[string]int
+	callers      map[string]int
+	templates    map[string]int
+	errorsPerMin map[string]int
+	routines     map[string]int
+}
+
+/**
+ * 创建一个日志统计收集器
+ */
+func newStatsCollector() *statsCollector {
+	return &statsCollector{
+		levels:       make(map[string]int),
+		callers:      make(map[string]int),
+		templates:    make(map[string]int),
+		errorsPerMin: make(map[string]int),
+		routines:     make(map[string]int),
+	}
+}
+
+/**
+ * 读取并统计一个日志流（文本或JSON格式）
+ */
+func (collector *statsCollector) collect(in io.Reader) error {
+	reader := loglet.NewReader(in)
+	for {
+		msg, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if msg.Level() == "" {
+			continue //不属于任何日志的孤立行
+		}
+		collector.add(msg)
+	}
+}
+
+/**
+ * 统计一条日志
+ */
+func (collector *statsCollector) add(msg *loglet.LogMsg) {
+	collector.total++
+	collector.levels[msg.Level()]++
+	caller := msg.TargetPoint()
+	if match := routineRegex.FindStringSubmatchIndex(caller); match != nil {
+		collector.routines[caller[match[2]:match[3]]]++
+		caller = caller[:match[0]]
+	}
+	collector.callers[caller]++
+	collector.templates[getMsgTemplate(msg.Content())]++
+	if levelNums[msg.Level()] >= loglet.ERROR_LEVEL {
+		collector.errorsPerMin[msg.Time().Format("2006-01-02 15:04")]++
+	}
+}
+
+/**
+ * 获取消息的模板：只取首行，并将可变部分替换为占位符
+ */
+func getMsgTemplate(content string) string {
+	if lineEnd := strings.IndexByte(content, '\n'); lineEnd >= 0 {
+		content = content[:lineEnd]
+	}
+	for _, rule := range templateRules {
+		content = rule.regex.ReplaceAllString(content, rule.placeholder)
+	}
+	return content
+}
+
+/**
+ * 统计报告中的一项计数
+ */
+type statsCount struct {
+	Key   string `json:"key"`
+	Count int    `json:"count"`
+}
+
+/**
+ * 统计报告
+ */
+type statsReport struct {
+	Total         int          `json:"total"`
+	Levels        []statsCount `json:"levels"`
+	TopCallers    []statsCount `json:"top_callers"`
+	TopTemplates  []statsCount `json:"top_templates"`
+	ErrorsPerMin  []statsCount `json:"errors_per_minute"`
+	TopGoroutines []statsCount `json:"top_goroutines"`
+}
+
+/**
+ * 生成统计报告
+ */
+func (collector *statsCollector) report(top int) *statsReport {
+	report := &statsReport{Total: collector.total}
+	for _, level := range []string{loglet.DEBUG, loglet.INFO, loglet.WARN, loglet.ERROR, loglet.FATAL} {
+		report.Levels = append(report.Levels, statsCount{Key: level, Count: collector.levels[level]})
+	}
+	report.TopCallers = getTopCounts(collector.callers, top)
+	report.TopTemplates = getTopCounts(collector.templates, top)
+	report.TopGoroutines = getTopCounts(collector.routines, top)
+	report.ErrorsPerMin = getTopCounts(collector.errorsPerMin, 0)
+	sort.Slice(report.ErrorsPerMin, func(i, j int) bool {
+		return report.ErrorsPerMin[i].Key < report.ErrorsPerMin[j].Key
+	})
+	return report
+}
+
+/**
+ * 按计数从大到小排序，取前top项（top<=0时返回全部）
+ */
+func getTopCounts(counts map[string]int, top int) []statsCount {
+	result := make([]statsCount, 0, len(counts))
+	for key, count := range counts {
+		result = append(result, statsCount{Key: key, Count: count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Key < result[j].Key
+	})
+	if top > 0 && len(result) > top {
+		result = result[:top]
+	}
+	return result
+}
+
+/**
+ * 以表格形式输出统计报告
+ */
+func (report *statsReport) printTable(out io.Writer) {
+	fmt.Fprintf(out, "total entries: %d\n", report.Total)
+	printCountTable(out, "LEVEL", report.Levels)
+	printCountTable(out, "TOP CALLERS", report.TopCallers)
+	printCountTable(out, "TOP MESSAGE TEMPLATES", report.TopTemplates)
+	printCountTable(out, "BUSIEST GOROUTINES", report.TopGoroutines)
+	fmt.Fprintf(out, "\nERRORS PER MINUTE\n")
+	maxCount := 0
+	for _, item := range report.ErrorsPerMin {
+		if item.Count > maxCount {
+			maxCount = item.Count
+		}
+	}
+	for _, item := range report.ErrorsPerMin {
+		//计数全为0时maxCount为0，避免除零
+		barLen := 0
+		if maxCount > 0 {
+			barLen = item.Count * 50 / maxCount
+		}
+		if barLen == 0 {
+			barLen = 1
+		}
+		fmt.Fprintf(out, "%s %8d %s\n", item.Key, item.Count, strings.Repeat("#", barLen))
+	}
+}
+
+/**
+ * 输出一个计数表格
+ */
+func printCountTable(out io.Writer, title string, counts []statsCount) {
+	fmt.Fprintf(out, "\n%-10s %s\n", "COUNT", title)
+	for _, item := range counts {
+		fmt.Fprintf(out, "%-10d %s\n", item.Count, item.Key)
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStatsCollector(t *testing.T) {
+	content := "2024-01-01 10:00:00.000 a.go 1 main.main() [1] [ERROR] request 12 failed: \"timeout\"\ntrace\n" +
+		`{"time":"2024-01-01T10:00:10.000Z","level":"ERROR","caller":"a.go 1 main.main() [3]","msg":"request 13 failed: \"refused\""}` + "\n" +
+		"2024-01-01 10:01:00.000 b.go 5 main.work() [3] [INFO] worker 0x1f started\n"
+	collector := newStatsCollector()
+	if err := collector.collect(strings.NewReader(content)); err != nil {
+		t.Fatal(err)
+	}
+	report := collector.report(1)
+	if report.Total != 3 || report.Levels[3].Count != 2 || report.Levels[1].Count != 1 {
+		t.Errorf("unexpected level counts: %+v", report.Levels)
+	}
+	if len(report.TopCallers) != 1 || report.TopCallers[0] != (statsCount{Key: "a.go 1 main.main()", Count: 2}) {
+		t.Errorf("unexpected top callers: %+v", report.TopCallers)
+	}
+	if report.TopTemplates[0] != (statsCount{Key: "request <num> failed: <str>", Count: 2}) {
+		t.Errorf("unexpected top templates: %+v", report.TopTemplates)
+	}
+	if report.TopGoroutines[0] != (statsCount{Key: "3", Count: 2}) {
+		t.Errorf("unexpected top goroutines: %+v", report.TopGoroutines)
+	}
+	if len(report.ErrorsPerMin) == 0 {
+		t.Error("errors per minute should not be empty")
+	}
+}
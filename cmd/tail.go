@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/duhaifeng/loglet"
+)
+
+/**
+ * loglet tail：持续跟踪日志文件输出，日志文件被FileWriter滚动（重命名）后自动切换到新文件
+ */
+func runTail(args []string) error {
+	flags := flag.NewFlagSet("tail", flag.ContinueOnError)
+	level := flags.String("level", "", "only show entries at or above this level (DEBUG, INFO, WARN, ERROR, FATAL)")
+	caller := flags.String("caller", "", "only show entries whose caller location contains this substring")
+	since := flags.String("since", "", "only show entries logged at or after this time")
+	until := flags.String("until", "", "only show entries logged at or before this time")
+	lines := flags.Int("n", 10, "number of existing lines to show before following")
+	interval := flags.Duration("interval", 200*time.Millisecond, "poll interval for new content and rotation")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: loglet tail [options] <file>")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("exactly one log file is required")
+	}
+	filter, err := newEntryFilter(*level, *caller, *since, *until)
+	if err != nil {
+		return err
+	}
+	follower := &tailFollower{path: flags.Arg(0), filter: filter, out: os.Stdout, interval: *interval}
+	stop := make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	go func() {
+		<-signals
+		close(stop)
+	}()
+	return follower.follow(*lines, stop)
+}
+
+/**
+ * 日志文件跟踪器
+ */
+type tailFollower struct {
+	path     string
+	filter   *entryFilter
+	out      io.Writer
+	interval time.Duration
+	file     *os.File
+	fileInfo os.FileInfo
+	offset   int64
+	pending  []byte //末尾尚未写完整的行
+	matched  bool   //上一条日志是否满足过滤条件，多行日志的后续行沿用该结果
+}
+
+/**
+ * 先输出文件末尾的若干行，然后持续跟踪新写入的内容，直到stop被关闭
+ */
+func (follower *tailFollower) follow(backlogLines int, stop <-chan struct{}) error {
+	follower.matched = follower.filter.empty()
+	for {
+		err := follower.openFile(backlogLines)
+		if err == nil {
+			break
+		}
+		if !os.IsNotExist(err) {
+			return err
+		}
+		//文件尚不存在时等待其被创建
+		select {
+		case <-stop:
+			return nil
+		case <-time.After(follower.interval):
+		}
+	}
+	defer func() {
+		follower.file.Close()
+	}()
+	for {
+		if err := follower.readAvailable(); err != nil {
+			return err
+		}
+		if err := follower.checkRotation(); err != nil {
+			return err
+		}
+		select {
+		case <-stop:
+			return nil
+		case <-time.After(follower.interval):
+		}
+	}
+}
+
+/**
+ * 打开要跟踪的文件，并定位到倒数第backlogLines行的开头
+ */
+func (follower *tailFollower) openFile(backlogLines int) error {
+	file, err := os.Open(follower.path)
+	if err != nil {
+		return err
+	}
+	fileInfo, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	offset, err := findTailOffset(file, fileInfo.Size(), backlogLines)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	if _, err = file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return err
+	}
+	follower.file, follower.fileInfo, follower.offset = file, fileInfo, offset
+	return nil
+}
+
+/**
+ * 读取文件中新写入的内容并按行输出
+ */
+func (follower *tailFollower) readAvailable() error {
+	buf := make([]byte, 32*1024)
+	for {
+		readSize, err := follower.file.Read(buf)
+		if readSize > 0 {
+			follower.offset += int64(readSize)
+			follower.pending = append(follower.pending, buf[:readSize]...)
+			follower.emitLines()
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+/**
+ * 检查文件是否被滚动（路径指向了新的文件）或被截断
+ */
+func (follower *tailFollower) checkRotation() error {
+	pathInfo, err := os.Stat(follower.path)
+	if err != nil {
+		//滚动过程中旧文件已被重命名而新文件尚未创建，等待下次检查
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if !os.SameFile(follower.fileInfo, pathInfo) {
+		//读完旧文件中剩余的内容后切换到新文件
+		if err = follower.readAvailable(); err != nil {
+			return err
+		}
+		follower.flushPending()
+		follower.file.Close()
+		if err = follower.openFile(0); err != nil {
+			return err
+		}
+		_, err = follower.file.Seek(0, io.SeekStart)
+		follower.offset = 0
+		return err
+	}
+	if pathInfo.Size() < follower.offset {
+		//文件被截断，从头开始读取
+		follower.pending = nil
+		follower.offset = 0
+		_, err = follower.file.Seek(0, io.SeekStart)
+		return err
+	}
+	return nil
+}
+
+/**
+ * 输出缓冲中所有完整的行
+ */
+func (follower *tailFollower) emitLines() {
+	for {
+		lineEnd := bytes.IndexByte(follower.pending, '\n')
+		if lineEnd < 0 {
+			return
+		}
+		follower.emitLine(string(bytes.TrimSuffix(follower.pending[:lineEnd], []byte("\r"))))
+		follower.pending = follower.pending[lineEnd+1:]
+	}
+}
+
+/**
+ * 输出缓冲中残留的不完整行（切换文件时使用）
+ */
+func (follower *tailFollower) flushPending() {
+	if len(follower.pending) > 0 {
+		follower.emitLine(string(follower.pending))
+		follower.pending = nil
+	}
+}
+
+/**
+ * 按过滤条件输出一行
+ */
+func (follower *tailFollower) emitLine(line string) {
+	if msg, err := loglet.ParseLine([]byte(line)); err == nil {
+		follower.matched = follower.filter.match(msg)
+	}
+	if follower.matched {
+		fmt.Fprintln(follower.out, line)
+	}
+}
+
+/**
+ * 从文件末尾向前查找倒数第lines行的起始偏移量
+ */
+func findTailOffset(file *os.File, size int64, lines int) (int64, error) {
+	if lines <= 0 {
+		return size, nil
+	}
+	buf := make([]byte, 4096)
+	lineCount := 0
+	pos := size
+	for pos > 0 {
+		readSize := int64(len(buf))
+		if pos < readSize {
+			readSize = pos
+		}
+		pos -= readSize
+		if _, err := file.ReadAt(buf[:readSize], pos); err != nil {
+			return 0, err
+		}
+		for i := readSize - 1; i >= 0; i-- {
+			//文件末尾的换行符不算作新的一行
+			if buf[i] != '\n' || pos+i == size-1 {
+				continue
+			}
+			lineCount++
+			if lineCount == lines {
+				return pos + i + 1, nil
+			}
+		}
+	}
+	return 0, nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+/**
+ * 并发安全的输出缓冲
+ */
+type syncBuffer struct {
+	sync.Mutex
+	buf bytes.Buffer
+}
+
+func (buffer *syncBuffer) Write(p []byte) (int, error) {
+	buffer.Lock()
+	defer buffer.Unlock()
+	return buffer.buf.Write(p)
+}
+
+func (buffer *syncBuffer) String() string {
+	buffer.Lock()
+	defer buffer.Unlock()
+	return buffer.buf.String()
+}
+
+func appendFile(t *testing.T, path string, content string) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if _, err = file.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+}
+
+/**
+ * 轮询等待输出中出现指定内容，超时后测试失败
+ */
+func waitForOutput(t *testing.T, out *syncBuffer, expected string) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); !strings.Contains(out.String(), expected); {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %q, got:\n%s", expected, out.String())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestTailFollowRotation(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "app.log")
+	appendFile(t, logFile, "2024-01-01 10:00:00.000 a.go 1 main.main() [1] [INFO] old backlog\n")
+
+	filter, err := newEntryFilter("warn", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := new(syncBuffer)
+	follower := &tailFollower{path: logFile, filter: filter, out: out, interval: 10 * time.Millisecond}
+	stop := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- follower.follow(10, stop)
+	}()
+
+	appendFile(t, logFile, "2024-01-01 10:00:01.000 a.go 2 main.main() [1] [WARN] before rotate\nstack line\n")
+	appendFile(t, logFile, "2024-01-01 10:00:02.000 a.go 3 main.main() [1] [DEBUG] filtered\nfiltered stack\n")
+	//确认已经读到旧文件的内容后再滚动
+	waitForOutput(t, out, "stack line\n")
+	if err = os.Rename(logFile, strings.TrimSuffix(logFile, ".log")+".20240101_100003.000.log"); err != nil {
+		t.Fatal(err)
+	}
+	appendFile(t, logFile, "2024-01-01 10:00:04.000 a.go 4 main.main() [1] [ERROR] after rotate\n")
+	waitForOutput(t, out, "after rotate\n")
+	close(stop)
+	if err = <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "2024-01-01 10:00:01.000 a.go 2 main.main() [1] [WARN] before rotate\nstack line\n" +
+		"2024-01-01 10:00:04.000 a.go 4 main.main() [1] [ERROR] after rotate\n"
+	if out.String() != expected {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -26,6 +27,16 @@ const (
 	FATAL_LEVEL = 4
 )
 
+/**
+ * 文本日志行首的时间格式
+ */
+const textTimeLayout = "2006-01-02 15:04:05.000"
+
+/**
+ * 日志模块函数名的前缀，用于在调用栈中识别日志模块内部的调用（子包logtest、cmd等不在其中）
+ */
+const loggerPkgPrefix = "github.com/duhaifeng/loglet."
+
 /**
  * 日志输出消息包装
  */
@@ -34,6 +45,90 @@ type LogMsg struct {
 	msgTime     time.Time
 	targetPoint string
 	msgContent  string
+	fields      []Field //日志附加的键值对字段（例如slog的属性），按添加顺序输出
+	loggerName  string  //产生该日志的日志实例名称，用于路由规则匹配
+}
+
+/**
+ * 日志附加字段定义，分组字段的键以"组名.键名"的形式展开
+ */
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+/**
+ * 获取日志级别
+ */
+func (msg *LogMsg) Level() string {
+	return msg.msgLevel
+}
+
+/**
+ * 获取日志产生的时间
+ */
+func (msg *LogMsg) Time() time.Time {
+	return msg.msgTime
+}
+
+/**
+ * 获取日志记录点（文件名 行号 函数名() [goroutine号]）
+ */
+func (msg *LogMsg) TargetPoint() string {
+	return msg.targetPoint
+}
+
+/**
+ * 获取产生该日志的日志实例名称，未设置名称时为空
+ */
+func (msg *LogMsg) LoggerName() string {
+	return msg.loggerName
+}
+
+/**
+ * 获取日志内容
+ */
+func (msg *LogMsg) Content() string {
+	return msg.msgContent
+}
+
+/**
+ * 获取日志附加字段（返回副本，修改不影响原日志）
+ */
+func (msg *LogMsg) Fields() []Field {
+	return append([]Field(nil), msg.fields...)
+}
+
+/**
+ * 修改日志内容（供钩子使用）
+ */
+func (msg *LogMsg) SetContent(content string) {
+	msg.msgContent = content
+}
+
+/**
+ * 设置附加字段，已存在同名字段时替换其值，否则追加到末尾（供钩子使用）
+ */
+func (msg *LogMsg) SetField(key string, value interface{}) {
+	for i := range msg.fields {
+		if msg.fields[i].Key == key {
+			msg.fields[i].Value = value
+			return
+		}
+	}
+	msg.fields = append(msg.fields, Field{Key: key, Value: value})
+}
+
+/**
+ * 删除附加字段（供钩子使用）
+ */
+func (msg *LogMsg) RemoveField(key string) {
+	for i := range msg.fields {
+		if msg.fields[i].Key == key {
+			msg.fields = append(msg.fields[:i], msg.fields[i+1:]...)
+			return
+		}
+	}
 }
 
 /**
@@ -41,51 +136,143 @@ type LogMsg struct {
  * 2012-09-20 15:56:12  [ com.homer.HMain.printLog(HMain.java:24):java.lang.Class:http-bio-9980-exec-3:0 ] - [ DEBUG ]  log4j debug
  */
 func (msg *LogMsg) getFormattedMsg() string {
-	timeStr := msg.msgTime.Format("2006-01-02 15:04:05.000")
-	return timeStr + " " + msg.targetPoint + " [" + msg.msgLevel + "] " + msg.msgContent
+	timeStr := msg.msgTime.Format(textTimeLayout)
+	return timeStr + " " + msg.targetPoint + " [" + msg.msgLevel + "] " + msg.msgContent + msg.getFormattedFields()
 }
 
 /**
- * 从运行堆栈中获取日志产生的代码点
+ * 将附加字段格式化为" key=value"的形式，值中包含空白、引号或等号时加引号转义
  */
-func getLoggingPoint(offset int) string {
-	/**
-	runtime.Stack()返回格式：
-	goroutine 18 [running]:
-	runtime/debug.Stack(0x0, 0x0, 0x0)
-		/usr/local/go/src/runtime/debug/stack.go:24 +0xbe
-	*/
-	//查找当前的goroutine号（位于调用栈的的第一行中）
-	stackBuf := make([]byte, 64)
-	bufSize := runtime.Stack(stackBuf, false)
-	stackBuf = stackBuf[:bufSize]
-	stackBuf = bytes.TrimPrefix(stackBuf, []byte("goroutine "))
-	stackBuf = stackBuf[:bytes.IndexByte(stackBuf, ' ')]
-	routineNo := string(stackBuf)
+func (msg *LogMsg) getFormattedFields() string {
+	if len(msg.fields) == 0 {
+		return ""
+	}
+	var buf strings.Builder
+	for _, field := range msg.fields {
+		buf.WriteString(" ")
+		buf.WriteString(field.Key)
+		buf.WriteString("=")
+		buf.WriteString(formatFieldValue(field.Value))
+	}
+	return buf.String()
+}
 
-	//查找日志记录点的函数名、文件及行号
-	outerCallerIndex := 0
+/**
+ * 将字段值转换为字符串
+ */
+func formatFieldValue(value interface{}) string {
+	valueStr := fmt.Sprint(value)
+	if valueStr == "" || strings.ContainsAny(valueStr, " \t\r\n\"=") {
+		return strconv.Quote(valueStr)
+	}
+	return valueStr
+}
+
+/**
+ * 从运行堆栈中获取日志产生的代码点，skipPkgs用于越过日志模块外层的中转函数（例如标准库log包）
+ */
+func getLoggingPoint(offset int, skipPkgs ...string) string {
+	//runtime.Stack的耗时与调用栈深度成正比，因此直接在这里获取goroutine号，不再经过一层函数调用
+	stackBuf := make([]byte, 64)
+	routineNo := parseRoutineNo(stackBuf[:runtime.Stack(stackBuf, false)])
+	//查找日志记录点的函数名、文件及行号（通过CallersFrames展开，避免内联导致的函数识别错误），越过runtime.Callers及本函数
 	pcs := make([]uintptr, 25)
-	callDepth := runtime.Callers(0, pcs)
+	callDepth := runtime.Callers(2, pcs)
+	frames := make([]runtime.Frame, 0, callDepth)
+	callerFrames := runtime.CallersFrames(pcs[:callDepth])
+	for {
+		frame, more := callerFrames.Next()
+		frames = append(frames, frame)
+		if !more {
+			break
+		}
+	}
+	callDepth = len(frames)
+	outerCallerIndex := 0
 	for i := callDepth - 1; i >= 0; i-- {
-		pc := pcs[i]
-		funcInfo := runtime.FuncForPC(pc)
-		//如果发现了loglet包路径，则说明进入了日志模块内部，获取上一次调用作为日志记录点
-		if strings.Contains(funcInfo.Name(), "loglet") {
+		//如果发现了loglet包的函数，则说明进入了日志模块内部，获取上一次调用作为日志记录点
+		if strings.HasPrefix(frames[i].Function, loggerPkgPrefix) {
 			outerCallerIndex = i + 1
 			break
 		}
 	}
+	//越过指定包中的中转调用，例如经由log.Logger输出时需要越过log包内部的函数
+	for outerCallerIndex < callDepth-1 && hasAnyPrefix(frames[outerCallerIndex].Function, skipPkgs) {
+		outerCallerIndex++
+	}
 	//将日志记录点进行偏移（前提是外部进行了指定）
 	if outerCallerIndex+offset < callDepth && outerCallerIndex+offset >= 0 {
 		outerCallerIndex += offset
 	}
-	outerCallerPc := pcs[outerCallerIndex]
-	funcInfo := runtime.FuncForPC(outerCallerPc)
-	filePath, line := funcInfo.FileLine(outerCallerPc)
-	funcName := funcInfo.Name()
-	file := filePath[strings.LastIndex(filePath, "/")+1:]
-	return fmt.Sprintf("%s %d %s() [%s]", file, line, funcName, routineNo)
+	if outerCallerIndex >= callDepth {
+		outerCallerIndex = callDepth - 1
+	}
+	return formatLoggingPoint(frames[outerCallerIndex], routineNo)
+}
+
+/**
+ * 根据程序计数器获取日志产生的代码点（例如slog.Record中已经记录了调用点）
+ */
+func getLoggingPointByPc(pc uintptr) string {
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	stackBuf := make([]byte, 64)
+	return formatLoggingPoint(frame, parseRoutineNo(stackBuf[:runtime.Stack(stackBuf, false)]))
+}
+
+/**
+ * 将调用栈帧格式化为日志记录点：文件名 行号 函数名() [goroutine号]
+ */
+func formatLoggingPoint(frame runtime.Frame, routineNo string) string {
+	file := frame.File[strings.LastIndex(frame.File, "/")+1:]
+	return fmt.Sprintf("%s %d %s() [%s]", file, frame.Line, frame.Function, routineNo)
+}
+
+/**
+ * 从调用栈的第一行中解析goroutine号
+ */
+func parseRoutineNo(stackBuf []byte) string {
+	/**
+	runtime.Stack()返回格式：
+	goroutine 18 [running]:
+	runtime/debug.Stack(0x0, 0x0, 0x0)
+		/usr/local/go/src/runtime/debug/stack.go:24 +0xbe
+	*/
+	stackBuf = bytes.TrimPrefix(stackBuf, []byte("goroutine "))
+	stackBuf = stackBuf[:bytes.IndexByte(stackBuf, ' ')]
+	return string(stackBuf)
+}
+
+/**
+ * 获取日志级别对应的数字，未知级别返回-1
+ */
+func getLevelNum(level string) int {
+	level = strings.ToUpper(level)
+	switch level {
+	case DEBUG:
+		return DEBUG_LEVEL
+	case INFO:
+		return INFO_LEVEL
+	case WARN:
+		return WARN_LEVEL
+	case ERROR:
+		return ERROR_LEVEL
+	case FATAL:
+		return FATAL_LEVEL
+	default:
+		return -1
+	}
+}
+
+/**
+ * 判断字符串是否以任一前缀开头
+ */
+func hasAnyPrefix(str string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(str, prefix) {
+			return true
+		}
+	}
+	return false
 }
 
 /**
@@ -0,0 +1,92 @@
+package loglet
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+/**
+ * JSON格式日志的时间格式（带毫秒及时区）
+ */
+const jsonTimeLayout = "2006-01-02T15:04:05.000Z07:00"
+
+/**
+ * 日志格式化器抽象定义，将一条日志转换为要输出的字节（包括行尾换行符）
+ */
+type Formatter interface {
+	Format(msg *LogMsg) []byte
+}
+
+/**
+ * 文本格式化器，输出格式与文件日志一致
+ */
+type TextFormatter struct {
+}
+
+/**
+ * 将日志格式化为一行文本
+ */
+func (formatter *TextFormatter) Format(msg *LogMsg) []byte {
+	return []byte(msg.getFormattedMsg() + "\n")
+}
+
+/**
+ * JSON格式化器，每条日志输出为一行JSON
+ */
+type JSONFormatter struct {
+}
+
+/**
+ * JSON格式日志的输出结构
+ */
+type jsonLogMsg struct {
+	Time   string                 `json:"time"`
+	Level  string                 `json:"level"`
+	Logger string                 `json:"logger,omitempty"`
+	Caller string                 `json:"caller"`
+	Msg    string                 `json:"msg"`
+	Fields map[string]interface{} `json:"fields,omitempty"`
+}
+
+/**
+ * 将日志格式化为一行JSON
+ */
+func (formatter *JSONFormatter) Format(msg *LogMsg) []byte {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	err := encoder.Encode(newJSONLogMsg(msg))
+	if err != nil {
+		printError("can not format log to json: %s.", err.Error())
+		return []byte(msg.getFormattedMsg() + "\n")
+	}
+	return buf.Bytes()
+}
+
+/**
+ * 将日志转换为JSON格式的输出结构
+ */
+func newJSONLogMsg(msg *LogMsg) *jsonLogMsg {
+	jsonMsg := &jsonLogMsg{Time: msg.msgTime.Format(jsonTimeLayout), Level: msg.msgLevel, Logger: msg.loggerName, Caller: msg.targetPoint, Msg: msg.msgContent}
+	if len(msg.fields) > 0 {
+		jsonMsg.Fields = make(map[string]interface{}, len(msg.fields))
+		for _, field := range msg.fields {
+			jsonMsg.Fields[field.Key] = getJSONFieldValue(field.Value)
+		}
+	}
+	return jsonMsg
+}
+
+/**
+ * 获取字段值的JSON表示，无法序列化的值（例如函数、管道）及error转换为字符串
+ */
+func getJSONFieldValue(value interface{}) interface{} {
+	if err, ok := value.(error); ok {
+		return err.Error()
+	}
+	if _, err := json.Marshal(value); err != nil {
+		return fmt.Sprint(value)
+	}
+	return value
+}
@@ -0,0 +1,70 @@
+package loglet
+
+import (
+	"strings"
+)
+
+/**
+ * 日志钩子抽象定义，在日志生成之后、分发到书写器之前执行
+ * 钩子可以修改日志（例如追加字段、改写内容），也可以返回false否决该日志
+ */
+type Hook interface {
+	Levels() []string      //钩子关注的日志级别，返回空时对所有级别生效
+	Fire(msg *LogMsg) bool //返回false表示丢弃该日志，后续钩子及书写器均不再处理
+}
+
+/**
+ * 函数形式的钩子，对所有级别生效
+ */
+type HookFunc func(msg *LogMsg) bool
+
+/**
+ * 对所有级别生效
+ */
+func (hookFunc HookFunc) Levels() []string {
+	return nil
+}
+
+/**
+ * 执行钩子函数
+ */
+func (hookFunc HookFunc) Fire(msg *LogMsg) bool {
+	return hookFunc(msg)
+}
+
+/**
+ * 已注册的钩子及其关注的级别
+ */
+type registeredHook struct {
+	hook   Hook
+	levels map[string]bool //为nil时对所有级别生效
+}
+
+/**
+ * 注册一个钩子，钩子按注册顺序执行（应在开始输出日志之前完成注册）
+ */
+func (logger *loggerBase) AddHook(hook Hook) {
+	registered := registeredHook{hook: hook}
+	if levels := hook.Levels(); len(levels) > 0 {
+		registered.levels = make(map[string]bool, len(levels))
+		for _, level := range levels {
+			registered.levels[strings.ToUpper(level)] = true
+		}
+	}
+	logger.hooks = append(logger.hooks, registered)
+}
+
+/**
+ * 依次执行关注该级别的钩子，任一钩子否决时返回false
+ */
+func (logger *loggerBase) fireHooks(msg *LogMsg) bool {
+	for _, registered := range logger.hooks {
+		if registered.levels != nil && !registered.levels[msg.msgLevel] {
+			continue
+		}
+		if !registered.hook.Fire(msg) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,60 @@
+package loglet
+
+import (
+	"strings"
+	"testing"
+)
+
+/**
+ * 只对ERROR级别生效的告警钩子
+ */
+type alertHook struct {
+	alerts []string
+}
+
+func (hook *alertHook) Levels() []string {
+	return []string{"error"}
+}
+
+func (hook *alertHook) Fire(msg *LogMsg) bool {
+	hook.alerts = append(hook.alerts, msg.Content())
+	return true
+}
+
+func TestHooks(t *testing.T) {
+	logger := NewLogger()
+	writer := new(captureWriter)
+	logger.CloseWriters()
+	logger.RegisterWriter("capture", writer)
+
+	var order []string
+	logger.AddHook(HookFunc(func(msg *LogMsg) bool {
+		order = append(order, "host")
+		msg.SetField("host", "node1")
+		return true
+	}))
+	logger.AddHook(HookFunc(func(msg *LogMsg) bool {
+		order = append(order, "health")
+		return !strings.Contains(msg.Content(), "/healthz")
+	}))
+	alert := new(alertHook)
+	logger.AddHook(alert)
+
+	logger.Info("GET /healthz")
+	logger.Info("GET /api")
+	logger.Error("db down")
+
+	msgs := writer.messages()
+	if len(msgs) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(msgs))
+	}
+	if !strings.HasSuffix(msgs[0].getFormattedMsg(), "GET /api host=node1") {
+		t.Errorf("host field should be added: %s", msgs[0].getFormattedMsg())
+	}
+	if len(alert.alerts) != 1 || alert.alerts[0] != "db down" {
+		t.Errorf("alert hook should only fire for errors: %v", alert.alerts)
+	}
+	if strings.Join(order[:2], ",") != "host,health" {
+		t.Errorf("hooks should fire in registration order: %v", order)
+	}
+}
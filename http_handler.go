@@ -0,0 +1,187 @@
+package loglet
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/**
+ * 日志查看页面模板，页面加载后通过Server-Sent Events实时追加新日志
+ */
+var logPageTemplate = template.Must(template.New("logs").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>loglet</title>
+<style>
+body { font-family: monospace; font-size: 12px; margin: 8px; }
+form { margin-bottom: 8px; }
+.log { white-space: pre-wrap; border-bottom: 1px solid #eee; padding: 1px 0; }
+.DEBUG { color: #3465a4; } .INFO { color: #4e9a06; } .WARN { color: #c4a000; }
+.ERROR { color: #cc0000; } .FATAL { color: #cc0000; font-weight: bold; }
+</style>
+</head>
+<body>
+<form method="get">
+level <select name="level">{{range .Levels}}<option{{if eq . $.Level}} selected{{end}}>{{.}}</option>{{end}}</select>
+caller <input name="caller" value="{{.Caller}}">
+text <input name="q" value="{{.Text}}">
+<input type="submit" value="filter">
+</form>
+<div id="logs">{{range .Msgs}}<div class="log {{.Level}}">{{.Time}} {{.Caller}} [{{.Level}}] {{.Msg}}</div>{{end}}</div>
+<script>
+var source = new EventSource(window.location.pathname + "?stream=1&" + window.location.search.substring(1));
+source.onmessage = function(event) {
+	var msg = JSON.parse(event.data);
+	var div = document.createElement("div");
+	div.className = "log " + msg.level;
+	div.textContent = msg.time + " " + msg.caller + " [" + msg.level + "] " + msg.msg;
+	document.getElementById("logs").appendChild(div);
+	window.scrollTo(0, document.body.scrollHeight);
+};
+</script>
+</body>
+</html>
+`))
+
+/**
+ * 日志查看接口，展示内存环形缓冲中的近期日志，并可通过Server-Sent Events实时推送新日志
+ * 查询参数：level（最低级别）、caller（代码点子串）、q（内容子串）、format（json或html）、stream（为1时实时推送）
+ */
+type HTTPHandler struct {
+	ring              *RingWriter
+	streamBufferSize  int           //每个实时推送连接的缓冲条数，客户端消费过慢时丢弃新日志
+	heartbeatInterval time.Duration //实时推送的心跳间隔，避免空闲连接被代理断开
+}
+
+/**
+ * 创建一个基于内存环形缓冲书写器的日志查看接口
+ */
+func NewHTTPHandler(ring *RingWriter) *HTTPHandler {
+	return &HTTPHandler{ring: ring, streamBufferSize: 256, heartbeatInterval: 15 * time.Second}
+}
+
+/**
+ * 处理日志查看请求
+ */
+func (handler *HTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	filter := newHTTPLogFilter(r)
+	if r.URL.Query().Get("stream") == "1" || strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		handler.serveStream(w, r, filter)
+		return
+	}
+	msgs := make([]*jsonLogMsg, 0)
+	for _, msg := range handler.ring.Snapshot() {
+		if filter.match(msg) {
+			msgs = append(msgs, newJSONLogMsg(msg))
+		}
+	}
+	if limit, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && limit > 0 && len(msgs) > limit {
+		msgs = msgs[len(msgs)-limit:]
+	}
+	format := r.URL.Query().Get("format")
+	if format == "json" || (format == "" && strings.Contains(r.Header.Get("Accept"), "application/json")) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		json.NewEncoder(w).Encode(msgs)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	pageData := map[string]interface{}{
+		"Levels": []string{DEBUG, INFO, WARN, ERROR, FATAL},
+		"Level":  filter.level,
+		"Caller": filter.caller,
+		"Text":   filter.text,
+		"Msgs":   msgs,
+	}
+	if err := logPageTemplate.Execute(w, pageData); err != nil {
+		printError("can not render log page: %s.", err.Error())
+	}
+}
+
+/**
+ * 通过Server-Sent Events实时推送新日志，直到客户端断开
+ * 推送经由订阅缓冲转发，客户端过慢时丢弃日志并通过dropped事件告知，不会阻塞日志写入
+ */
+func (handler *HTTPHandler) serveStream(w http.ResponseWriter, r *http.Request, filter *httpLogFilter) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	subscription := handler.ring.Subscribe(handler.streamBufferSize)
+	defer subscription.Close()
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(handler.heartbeatInterval)
+	defer heartbeat.Stop()
+	var reportedDrops uint64
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+		case msg, ok := <-subscription.Messages():
+			if !ok {
+				return
+			}
+			if filter.match(msg) {
+				data, _ := json.Marshal(newJSONLogMsg(msg))
+				fmt.Fprintf(w, "data: %s\n\n", data)
+			}
+		}
+		if dropped := subscription.Dropped(); dropped > reportedDrops {
+			fmt.Fprintf(w, "event: dropped\ndata: %d\n\n", dropped-reportedDrops)
+			reportedDrops = dropped
+		}
+		flusher.Flush()
+	}
+}
+
+/**
+ * 日志查看接口的过滤条件
+ */
+type httpLogFilter struct {
+	level    string
+	levelNum int
+	caller   string
+	text     string
+}
+
+/**
+ * 从请求参数中解析过滤条件
+ */
+func newHTTPLogFilter(r *http.Request) *httpLogFilter {
+	query := r.URL.Query()
+	filter := &httpLogFilter{level: strings.ToUpper(query.Get("level")), caller: query.Get("caller"), text: query.Get("q")}
+	filter.levelNum = getLevelNum(filter.level)
+	if filter.levelNum < 0 {
+		filter.level, filter.levelNum = DEBUG, DEBUG_LEVEL
+	}
+	return filter
+}
+
+/**
+ * 判断日志是否满足过滤条件
+ */
+func (filter *httpLogFilter) match(msg *LogMsg) bool {
+	if getLevelNum(msg.msgLevel) < filter.levelNum {
+		return false
+	}
+	if filter.caller != "" && !strings.Contains(msg.targetPoint, filter.caller) {
+		return false
+	}
+	if filter.text != "" && !strings.Contains(msg.msgContent+msg.getFormattedFields(), filter.text) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,78 @@
+package loglet
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTTPHandler(t *testing.T) {
+	ring := NewRingWriter(nil)
+	ring.WriteLog(&LogMsg{msgLevel: DEBUG, msgTime: time.Now(), targetPoint: "a.go 1 main.main() [1]", msgContent: "debug noise"})
+	ring.WriteLog(&LogMsg{msgLevel: ERROR, msgTime: time.Now(), targetPoint: "b.go 2 main.work() [2]", msgContent: "disk <full>"})
+	server := httptest.NewServer(NewHTTPHandler(ring))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "?format=json&level=warn&caller=b.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msgs []map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&msgs)
+	resp.Body.Close()
+	if len(msgs) != 1 || msgs[0]["msg"] != "disk <full>" {
+		t.Errorf("unexpected json result: %v", msgs)
+	}
+
+	resp, err = http.Get(server.URL + "?q=full")
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := new(strings.Builder)
+	bufio.NewReader(resp.Body).WriteTo(page)
+	resp.Body.Close()
+	if !strings.Contains(page.String(), "disk &lt;full&gt;") || strings.Contains(page.String(), "debug noise") {
+		t.Errorf("unexpected html page: %s", page.String())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"?stream=1&q=live", nil)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	ring.WriteLog(&LogMsg{msgLevel: INFO, msgTime: time.Now(), msgContent: "ignored"})
+	ring.WriteLog(&LogMsg{msgLevel: INFO, msgTime: time.Now(), msgContent: "live message"})
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if strings.HasPrefix(scanner.Text(), "data: ") {
+			if !strings.Contains(scanner.Text(), "live message") {
+				t.Errorf("unexpected event: %s", scanner.Text())
+			}
+			return
+		}
+	}
+	t.Error("no event received")
+}
+
+func TestRingSubscriptionDrop(t *testing.T) {
+	ring := NewRingWriter(nil)
+	subscription := ring.Subscribe(1)
+	ring.WriteLog(&LogMsg{msgLevel: INFO, msgContent: "first"})
+	ring.WriteLog(&LogMsg{msgLevel: INFO, msgContent: "second"})
+	if subscription.Dropped() != 1 {
+		t.Errorf("expected 1 dropped message, got %d", subscription.Dropped())
+	}
+	subscription.Close()
+	ring.WriteLog(&LogMsg{msgLevel: INFO, msgContent: "third"})
+	if msg := <-subscription.Messages(); msg.msgContent != "first" {
+		t.Errorf("unexpected message: %s", msg.msgContent)
+	}
+}
@@ -32,63 +32,133 @@ func (logger *Logger) Init(configs map[string]string) {
 	logger.CloseWriters()
 
 	logger.loggerBase.SetLogLevel(configs["log_level"])
+	logger.SetName(configs["name"])
+	redactor, err := newRedactorFromConfig(configs)
+	if err != nil {
+		printError("log redact config error: %s. redaction disabled.", err.Error())
+	}
+	logger.SetRedactor(redactor)
 	logger.logWriters = make(map[string]LogWriter)
 	writers := strings.Split(configs["writers"], ",")
 	for _, writerName := range writers {
-		if strings.TrimSpace(writerName) == "console" {
+		writerName = strings.TrimSpace(writerName)
+		if writerName == "console" {
 			logger.RegisterWriter("console", logger.createConsoleWriter(configs))
 		}
-		if strings.TrimSpace(writerName) == "file" {
-			logger.RegisterWriter("file", logger.createFileWriter(configs))
+		if writerName == "file" {
+			logger.RegisterWriter("file", logger.createFileWriter(configs, ""))
+		}
+		//命名的文件书写器，例如file:error，其配置项以名称为前缀：error.log_file、error.max_size、error.file_number
+		if strings.HasPrefix(writerName, "file:") {
+			name := strings.TrimSpace(strings.TrimPrefix(writerName, "file:"))
+			logger.RegisterWriter(name, logger.createFileWriter(configs, name+"."))
 		}
 	}
 	if len(logger.logWriters) == 0 {
 		logger.RegisterWriter("console", logger.createConsoleWriter(configs))
 	}
+	//书写器可以单独配置输出级别，例如file_log_level=info
+	logger.clearWriterLevels()
+	for name := range logger.logWriters {
+		if writerLevel := configs[name+"_log_level"]; writerLevel != "" {
+			logger.SetWriterLevel(name, writerLevel)
+		}
+	}
+	//路由规则，例如routes=errors,app，未配置时日志分发到全部书写器
+	logger.ClearRoutes()
+	for _, route := range newRoutesFromConfig(configs) {
+		for _, name := range route.Writers {
+			if _, ok := logger.logWriters[name]; !ok {
+				printError("log route %s refers to unknown writer: %s.", route.Name, name)
+			}
+		}
+		logger.AddRoute(route)
+	}
 }
 
 /**
  * 创建一个控制台日志书写器
  */
 func (logger *Logger) createConsoleWriter(configs map[string]string) *ConsoleWriter {
-	consoleLogger := new(ConsoleWriter)
+	//console_color可选auto、always、never，默认auto
+	consoleLogger := NewConsoleWriter(configs["console_color"])
 	return consoleLogger
 }
 
 /**
- * 创建一个文件日志书写器
+ * 创建一个异步写入的文件日志书写器，prefix为命名文件书写器的配置项前缀（默认文件书写器为空）
+ * 异步队列可通过<prefix>queue_size、<prefix>overflow_policy（block、drop_newest、drop_oldest）配置
  */
-func (logger *Logger) createFileWriter(configs map[string]string) *FileWriter {
+func (logger *Logger) createFileWriter(configs map[string]string, prefix string) *AsyncWriter {
 	fileLogger := new(FileWriter)
-	fileLogger.Init()
-	fileLogger.SetFileBaseName(configs["log_file"])
-	fileSizeStr := strings.ToUpper(configs["max_size"])
-	fileSizeUnit := fileSizeStr[len(fileSizeStr)-1:] //取配置的最后一个字母作为日志文件大小的单位
-	fileSizeStr = strings.Replace(fileSizeStr, "K", "", -1)
-	fileSizeStr = strings.Replace(fileSizeStr, "M", "", -1)
-	fileSizeStr = strings.Replace(fileSizeStr, "G", "", -1)
-	fileSize, err := strconv.Atoi(fileSizeStr)
+	fileLogger.SetFileBaseName(configs[prefix+"log_file"])
+	//滚动文件的命名方式：timestamp（默认）、numbered、date
+	namingScheme, err := NamingSchemeByName(configs[prefix+"file_naming"])
+	if err != nil {
+		printError("log file naming config error: %s. use default: %s", err.Error(), NAMING_TIMESTAMP)
+		namingScheme = TimestampNaming{}
+	}
+	fileLogger.SetNamingScheme(namingScheme)
+	//multi_process=true时多个进程可以安全地写入同一日志文件
+	if strings.EqualFold(configs[prefix+"multi_process"], "true") {
+		if err = fileLogger.SetMultiProcess(true); err != nil {
+			printError("log file multi process config error: %s. disabled.", err.Error())
+		}
+	}
+	//reopen_on_signal=true时收到SIGHUP、SIGUSR1后重新打开日志文件，配合外部logrotate使用
+	if strings.EqualFold(configs[prefix+"reopen_on_signal"], "true") {
+		handleReopenSignalsOnce()
+	}
+	//fallback=console时文件无法写入（磁盘已满等）期间日志改为输出到控制台
+	if strings.EqualFold(configs[prefix+"fallback"], "console") {
+		fileLogger.SetFallbackWriter(logger.createConsoleWriter(configs))
+	}
+	//current_link=true时基名作为指向当前分段的软链接
+	if strings.EqualFold(configs[prefix+"current_link"], "true") {
+		if err = fileLogger.SetCurrentLink(true); err != nil {
+			printError("log file current link config error: %s. disabled.", err.Error())
+		}
+	}
+	fileSize, err := parseByteSize(configs[prefix+"max_size"])
 	if err != nil {
 		printError("log file size config error. use default size: 100M")
 		fileSize = 1024 * 1024 * 100
-	} else {
-		// modified by liwenqiao 2017-7-20
-		if fileSizeUnit == "K" {
-			fileSize = fileSize * 1024
-		} else if fileSizeUnit == "G" {
-			fileSize = fileSize * 1024 * 1024 * 1024
-		} else {
-			fileSize = fileSize * 1024 * 1024
-		}
 	}
-	fileLogger.SetRotateSize(int64(fileSize))
-	//设置要保留日志文件的个数
-	fileNum, err := strconv.Atoi(configs["file_number"])
+	fileLogger.SetRotateSize(fileSize)
+	//设置要保留历史日志文件的个数（不含当前文件，旧版本的file_number包含当前文件），max_files为file_number的别名
+	fileNumStr := configs[prefix+"max_files"]
+	if fileNumStr == "" {
+		fileNumStr = configs[prefix+"file_number"]
+	}
+	fileNum, err := strconv.Atoi(fileNumStr)
 	if err != nil {
 		printError("log file reserve number config error. use default: 10")
 		fileLogger.SetFileReserveNum(10)
 	} else {
 		fileLogger.SetFileReserveNum(fileNum)
 	}
-	return fileLogger
+	//按保留时长（例如14d）及总大小（例如5G）清理历史文件
+	retention := RetentionPolicy{MaxFiles: fileLogger.retention.MaxFiles}
+	if maxAge := configs[prefix+"max_age"]; maxAge != "" {
+		retention.MaxAge, err = parseRetentionAge(maxAge)
+		if err != nil {
+			printError("log file max age config error: %s. ignored.", err.Error())
+		}
+	}
+	if maxTotalSize := configs[prefix+"max_total_size"]; maxTotalSize != "" {
+		retention.MaxTotalSize, err = parseByteSize(maxTotalSize)
+		if err != nil {
+			printError("log file max total size config error: %s. ignored.", err.Error())
+		}
+	}
+	fileLogger.SetRetentionPolicy(retention)
+	//为了避免日志文件读写慢阻塞主进程，文件书写器通过异步队列写入
+	asyncOpts := &AsyncWriterOptions{OverflowPolicy: configs[prefix+"overflow_policy"]}
+	if queueSize := configs[prefix+"queue_size"]; queueSize != "" {
+		asyncOpts.QueueSize, err = strconv.Atoi(queueSize)
+		if err != nil {
+			printError("log queue size config error. use default: 10000")
+		}
+	}
+	return NewAsyncWriter(fileLogger, asyncOpts)
 }
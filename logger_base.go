@@ -2,7 +2,7 @@ package loglet
 
 import (
 	"fmt"
-	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -10,9 +10,18 @@ import (
  * 日志记录器对象抽象定义
  */
 type loggerBase struct {
+	levelCounts       [5]uint64 //各级别日志的计数（atomic操作，需位于结构体开头保证对齐）
 	logLevel          int
-	logPositionOffset int                  //允许外部定义一个偏移量，避免外部二次封装时日志都打在外面的封装点上
-	logWriters        map[string]LogWriter //为了防止配置中重复出现file、console等，采用map进行滤重
+	enabledLevel      int                       //logLevel与各书写器级别中的最低级别，低于该级别的日志不会分发到任何书写器
+	logPositionOffset int                       //允许外部定义一个偏移量，避免外部二次封装时日志都打在外面的封装点上
+	logWriters        map[string]LogWriter      //为了防止配置中重复出现file、console等，采用map进行滤重
+	writerLevels      map[string]int            //单个书写器的输出级别，未设置的书写器只受logLevel限制
+	writerMetrics     map[string]*writerMetrics //各书写器的分发条数及耗时统计
+	hooks             []registeredHook          //日志分发到书写器之前依次执行的钩子
+	redactor          *Redactor                 //敏感信息脱敏处理器，在钩子之后执行
+	loggerName        string                    //日志实例名称，用于路由规则匹配
+	routes            []registeredRoute         //路由规则，为空时日志分发到全部书写器
+	errorHandler      atomic.Value              //书写器内部错误的处理函数（ErrorHandler），为nil时打印到标准错误输出；书写器的后台协程中也会读取，因此原子读写
 }
 
 /**
@@ -20,6 +29,39 @@ type loggerBase struct {
  */
 func (logger *loggerBase) SetLogLevel(level string) {
 	logger.logLevel = logger.getLogLevelNum(level)
+	logger.updateEnabledLevel()
+}
+
+/**
+ * 设置单个书写器的输出级别（例如文件只记录INFO以上，而内存缓冲记录全部），
+ * 书写器级别可以低于logLevel，此时只有该书写器能收到低于logLevel的日志，未设置级别的书写器仍受logLevel限制
+ */
+func (logger *loggerBase) SetWriterLevel(name string, level string) {
+	if logger.writerLevels == nil {
+		logger.writerLevels = make(map[string]int)
+	}
+	logger.writerLevels[name] = logger.getLogLevelNum(level)
+	logger.updateEnabledLevel()
+}
+
+/**
+ * 清除全部书写器的单独输出级别
+ */
+func (logger *loggerBase) clearWriterLevels() {
+	logger.writerLevels = nil
+	logger.updateEnabledLevel()
+}
+
+/**
+ * 重新计算日志分发的最低级别：logLevel与各书写器级别中的最低者
+ */
+func (logger *loggerBase) updateEnabledLevel() {
+	logger.enabledLevel = logger.logLevel
+	for _, writerLevel := range logger.writerLevels {
+		if writerLevel < logger.enabledLevel {
+			logger.enabledLevel = writerLevel
+		}
+	}
 }
 
 /**
@@ -36,25 +78,123 @@ func (logger *loggerBase) RegisterWriter(name string, logWriter LogWriter) {
 	if logger.logWriters == nil {
 		logger.logWriters = make(map[string]LogWriter)
 	}
+	if logger.writerMetrics == nil {
+		logger.writerMetrics = make(map[string]*writerMetrics)
+	}
 	logger.logWriters[name] = logWriter
+	logger.writerMetrics[name] = new(writerMetrics)
+	//能自行报告错误的书写器（后台滚动、清理等）通过日志记录器的错误处理函数报告，并补充书写器名称
+	if reporter, ok := logWriter.(ErrorReporter); ok {
+		reporter.SetErrorHandler(func(err *WriterError) {
+			if err.Writer == "" {
+				err.Writer = name
+			}
+			logger.reportError(err)
+		})
+	}
+}
+
+/**
+ * 设置书写器内部错误的处理函数，传入nil时恢复默认行为（打印到标准错误输出）
+ */
+func (logger *loggerBase) SetErrorHandler(handler ErrorHandler) {
+	logger.errorHandler.Store(handler)
+}
+
+/**
+ * 将书写器内部错误交给错误处理函数
+ */
+func (logger *loggerBase) reportError(err *WriterError) {
+	handler, _ := logger.errorHandler.Load().(ErrorHandler)
+	if handler == nil {
+		handler = defaultErrorHandler
+	}
+	handler(err)
+}
+
+/**
+ * 报告书写器返回的错误，自行报告错误的书写器已经报告过，这里不再重复
+ */
+func (logger *loggerBase) reportWriterError(name string, logWriter LogWriter, op string, err error) {
+	if _, ok := logWriter.(ErrorReporter); ok || err == nil {
+		return
+	}
+	logger.reportError(&WriterError{Writer: name, Op: op, Err: err})
 }
 
 /**
  * 关闭所有的日志书写器
  */
 func (logger *loggerBase) CloseWriters() {
-	for _, logWriter := range logger.logWriters {
-		logWriter.Close()
+	for name, logWriter := range logger.logWriters {
+		logger.reportWriterError(name, logWriter, "close", logWriter.Close())
 	}
 	logger.logWriters = nil
+	logger.writerMetrics = nil
+}
+
+/**
+ * 设置敏感信息脱敏处理器，传入nil时关闭脱敏
+ */
+func (logger *loggerBase) SetRedactor(redactor *Redactor) {
+	logger.redactor = redactor
+}
+
+/**
+ * 执行钩子及脱敏后将日志分发到书写器，日志被钩子否决时直接丢弃
+ */
+func (logger *loggerBase) emitLog(msg *LogMsg) {
+	msg.loggerName = logger.loggerName
+	if !logger.fireHooks(msg) {
+		return
+	}
+	//脱敏放在钩子之后，保证钩子追加的字段同样被处理
+	if logger.redactor != nil {
+		logger.redactor.redactMsg(msg)
+	}
+	logger.writeLog(msg)
 }
 
 /**
  * 向日志缓存管道缓存日志
  */
 func (logger *loggerBase) writeLog(msg *LogMsg) {
-	for _, logWriter := range logger.logWriters {
-		logWriter.WriteLog(msg)
+	msgLevelNum := logger.getLogLevelNum(msg.msgLevel)
+	if msgLevelNum >= 0 && msgLevelNum < len(logger.levelCounts) {
+		atomic.AddUint64(&logger.levelCounts[msgLevelNum], 1)
+	}
+	if len(logger.routes) == 0 {
+		for name, logWriter := range logger.logWriters {
+			logger.writeLogTo(name, logWriter, msg, msgLevelNum)
+		}
+		return
+	}
+	//配置了路由规则时只发送到规则指定的书写器
+	for _, name := range logger.getRoutedWriters(msg) {
+		if logWriter, ok := logger.logWriters[name]; ok {
+			logger.writeLogTo(name, logWriter, msg, msgLevelNum)
+		}
+	}
+}
+
+/**
+ * 向单个书写器输出日志，并记录分发条数及耗时
+ */
+func (logger *loggerBase) writeLogTo(name string, logWriter LogWriter, msg *LogMsg, msgLevelNum int) {
+	//设置了单独级别的书写器只按自身级别过滤，其他书写器按logLevel过滤
+	writerLevel, ok := logger.writerLevels[name]
+	if !ok {
+		writerLevel = logger.logLevel
+	}
+	if msgLevelNum < writerLevel {
+		return
+	}
+	startTime := time.Now()
+	err := logWriter.WriteLog(msg)
+	logger.reportWriterError(name, logWriter, "write", err)
+	if metrics, ok := logger.writerMetrics[name]; ok {
+		metrics.latency.observe(time.Since(startTime))
+		atomic.AddUint64(&metrics.messages, 1)
 	}
 }
 
@@ -69,95 +209,81 @@ func (logger *loggerBase) getMsg(msg string, msgArgs ...interface{}) *LogMsg {
  * 写入Debug级别日志
  */
 func (logger *loggerBase) Debug(content string, contentArgs ...interface{}) {
-	if logger.logLevel > DEBUG_LEVEL {
+	if logger.enabledLevel > DEBUG_LEVEL {
 		return
 	}
 	msg := logger.getMsg(content, contentArgs...)
 	msg.msgLevel = DEBUG
-	logger.writeLog(msg)
+	logger.emitLog(msg)
 }
 
 /**
  * 写入Info级别日志
  */
 func (logger *loggerBase) Info(content string, contentArgs ...interface{}) {
-	if logger.logLevel > INFO_LEVEL {
+	if logger.enabledLevel > INFO_LEVEL {
 		return
 	}
 	msg := logger.getMsg(content, contentArgs...)
 	msg.msgLevel = INFO
-	logger.writeLog(msg)
+	logger.emitLog(msg)
 }
 
 /**
  * 写入Warning级别日志
  */
 func (logger *loggerBase) Warn(content string, contentArgs ...interface{}) {
-	if logger.logLevel > WARN_LEVEL {
+	if logger.enabledLevel > WARN_LEVEL {
 		return
 	}
 	msg := logger.getMsg(content, contentArgs...)
 	msg.msgLevel = WARN
-	logger.writeLog(msg)
+	logger.emitLog(msg)
 }
 
 /**
  * 写入Error级别日志
  */
 func (logger *loggerBase) Error(content interface{}, contentArgs ...interface{}) {
-	if logger.logLevel > ERROR_LEVEL {
+	if logger.enabledLevel > ERROR_LEVEL {
 		return
 	}
 	var msg *LogMsg
-	errContent, ok := content.(error)
-	if ok {
-		msg = logger.getMsg(errContent.Error(), contentArgs...)
-	}
-	contentStr, ok := content.(string)
-	{
-		msg = logger.getMsg(contentStr, contentArgs...)
+	switch typedContent := content.(type) {
+	case error:
+		msg = logger.getMsg(typedContent.Error(), contentArgs...)
+	case string:
+		msg = logger.getMsg(typedContent, contentArgs...)
+	default:
+		msg = logger.getMsg(fmt.Sprint(typedContent), contentArgs...)
 	}
 
 	msg.msgLevel = ERROR
-	logger.writeLog(msg)
+	logger.emitLog(msg)
 }
 
 /**
  * 写入Fatal级别日志
  */
 func (logger *loggerBase) Fatal(content string, contentArgs ...interface{}) {
-	if logger.logLevel > FATAL_LEVEL {
+	if logger.enabledLevel > FATAL_LEVEL {
 		return
 	}
 	msg := logger.getMsg(content, contentArgs...)
 	msg.msgLevel = FATAL
-	logger.writeLog(msg)
+	logger.emitLog(msg)
 }
 
 /**
  * 判断当前日志记录是否达到了输出的定义级别，如果未达到则丢弃上层传入的消息
  */
 func (logger *loggerBase) matchLogLevel(msg *LogMsg) bool {
-	return logger.getLogLevelNum(msg.msgLevel) >= logger.logLevel
+	return logger.getLogLevelNum(msg.msgLevel) >= logger.enabledLevel
 }
 
 /**
  * 获取日志级别对应的数字，便于判断
  */
 func (logger *loggerBase) getLogLevelNum(level string) int {
-	level = strings.ToUpper(level)
-	switch level {
-	case DEBUG:
-		return DEBUG_LEVEL
-	case INFO:
-		return INFO_LEVEL
-	case WARN:
-		return WARN_LEVEL
-	case ERROR:
-		return ERROR_LEVEL
-	case FATAL:
-		return FATAL_LEVEL
-	default:
-		return -1
-	}
+	return getLevelNum(level)
 }
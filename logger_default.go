@@ -0,0 +1,67 @@
+package loglet
+
+import (
+	"sync/atomic"
+)
+
+/**
+ * 进程级默认日志实例，通过atomic.Value存取，保证运行期间可以并发安全地替换
+ */
+var defaultLogger atomic.Value
+
+func init() {
+	defaultLogger.Store(NewLogger())
+}
+
+/**
+ * 获取进程级默认日志实例
+ */
+func Default() *Logger {
+	return defaultLogger.Load().(*Logger)
+}
+
+/**
+ * 替换进程级默认日志实例，传入nil时忽略
+ */
+func SetDefault(logger *Logger) {
+	if logger == nil {
+		return
+	}
+	defaultLogger.Store(logger)
+}
+
+/**
+ * 通过默认日志实例写入Debug级别日志
+ * 本函数位于loglet包内，日志记录点查找时会被自动越过，因此不需要额外指定偏移量
+ */
+func Debug(content string, contentArgs ...interface{}) {
+	Default().Debug(content, contentArgs...)
+}
+
+/**
+ * 通过默认日志实例写入Info级别日志
+ */
+func Info(content string, contentArgs ...interface{}) {
+	Default().Info(content, contentArgs...)
+}
+
+/**
+ * 通过默认日志实例写入Warning级别日志
+ */
+func Warn(content string, contentArgs ...interface{}) {
+	Default().Warn(content, contentArgs...)
+}
+
+/**
+ * 通过默认日志实例写入Error级别日志
+ */
+func Error(content interface{}, contentArgs ...interface{}) {
+	Default().Error(content, contentArgs...)
+}
+
+/**
+ * 通过默认日志实例写入Fatal级别日志
+ */
+func Fatal(content string, contentArgs ...interface{}) {
+	Default().Fatal(content, contentArgs...)
+}
@@ -0,0 +1,138 @@
+//go:build go1.21
+// +build go1.21
+
+package loglet
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+/**
+ * 基于loglet实现的slog.Handler，slog的输出经由loglet的书写器（文件滚动、统一格式等）落地
+ */
+type SlogHandler struct {
+	logger      *Logger
+	fields      []Field //通过WithAttrs预置的字段（键已带上分组前缀）
+	groupPrefix string  //通过WithGroup设置的分组前缀，形如"group1.group2."
+}
+
+/**
+ * 创建一个将slog日志转交给指定日志实例的Handler
+ */
+func NewSlogHandler(logger *Logger) *SlogHandler {
+	return &SlogHandler{logger: logger}
+}
+
+/**
+ * 创建一个以当前日志实例为输出的*slog.Logger
+ */
+func (logger *Logger) Slog() *slog.Logger {
+	return slog.New(NewSlogHandler(logger))
+}
+
+/**
+ * 判断slog级别是否达到了日志实例的输出级别
+ */
+func (handler *SlogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	_, levelNum := convertSlogLevel(level)
+	return levelNum >= handler.logger.enabledLevel
+}
+
+/**
+ * 将一条slog记录转换为LogMsg后交给日志实例的书写器
+ */
+func (handler *SlogHandler) Handle(ctx context.Context, record slog.Record) error {
+	msgLevel, levelNum := convertSlogLevel(record.Level)
+	if levelNum < handler.logger.enabledLevel {
+		return nil
+	}
+	msg := &LogMsg{msgLevel: msgLevel, msgTime: record.Time, msgContent: record.Message}
+	if msg.msgTime.IsZero() {
+		msg.msgTime = time.Now()
+	}
+	//slog已经记录了调用点，优先使用；未记录时按常规方式从调用栈中查找
+	if record.PC != 0 {
+		msg.targetPoint = getLoggingPointByPc(record.PC)
+	} else {
+		msg.targetPoint = getLoggingPoint(handler.logger.logPositionOffset)
+	}
+	msg.fields = make([]Field, 0, len(handler.fields)+record.NumAttrs())
+	msg.fields = append(msg.fields, handler.fields...)
+	record.Attrs(func(attr slog.Attr) bool {
+		msg.fields = appendSlogAttr(msg.fields, handler.groupPrefix, attr)
+		return true
+	})
+	handler.logger.emitLog(msg)
+	return nil
+}
+
+/**
+ * 返回一个预置了指定属性的新Handler
+ */
+func (handler *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return handler
+	}
+	newHandler := *handler
+	newHandler.fields = make([]Field, 0, len(handler.fields)+len(attrs))
+	newHandler.fields = append(newHandler.fields, handler.fields...)
+	for _, attr := range attrs {
+		newHandler.fields = appendSlogAttr(newHandler.fields, handler.groupPrefix, attr)
+	}
+	return &newHandler
+}
+
+/**
+ * 返回一个新Handler，其后添加的属性均归属于指定分组
+ */
+func (handler *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return handler
+	}
+	newHandler := *handler
+	newHandler.groupPrefix = handler.groupPrefix + name + "."
+	return &newHandler
+}
+
+/**
+ * 将slog属性展开为loglet字段，分组属性按"组名.键名"展开
+ */
+func appendSlogAttr(fields []Field, prefix string, attr slog.Attr) []Field {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return fields
+	}
+	if attr.Value.Kind() == slog.KindGroup {
+		groupAttrs := attr.Value.Group()
+		//键为空的分组直接内联到上一层
+		groupPrefix := prefix
+		if attr.Key != "" {
+			groupPrefix = prefix + attr.Key + "."
+		}
+		for _, groupAttr := range groupAttrs {
+			fields = appendSlogAttr(fields, groupPrefix, groupAttr)
+		}
+		return fields
+	}
+	return append(fields, Field{Key: prefix + attr.Key, Value: attr.Value.Any()})
+}
+
+/**
+ * 将slog级别映射为loglet级别，slog中高于Error的级别视为Fatal
+ */
+func convertSlogLevel(level slog.Level) (string, int) {
+	switch {
+	case level < slog.LevelInfo:
+		return DEBUG, DEBUG_LEVEL
+	case level < slog.LevelWarn:
+		return INFO, INFO_LEVEL
+	case level < slog.LevelError:
+		return WARN, WARN_LEVEL
+	case level < slog.LevelError+4:
+		return ERROR, ERROR_LEVEL
+	default:
+		return FATAL, FATAL_LEVEL
+	}
+}
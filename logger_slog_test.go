@@ -0,0 +1,38 @@
+//go:build go1.21
+// +build go1.21
+
+package loglet
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSlogHandler(t *testing.T) {
+	logger := NewLogger()
+	logger.SetLogLevel("info")
+	writer := new(captureWriter)
+	logger.RegisterWriter("capture", writer)
+
+	slogger := logger.Slog().With("host", "node1").WithGroup("req")
+	slogger.Debug("ignored")
+	slogger.Info("request done", "path", "/api", slog.Group("user", "id", 7, "name", "tom cat"))
+	slogger.Log(context.Background(), slog.LevelError+4, "fatal")
+
+	msgs := writer.messages()
+	if len(msgs) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(msgs))
+	}
+	formatted := msgs[0].getFormattedMsg()
+	if msgs[0].msgLevel != INFO || !strings.HasSuffix(formatted, `[INFO] request done host=node1 req.path=/api req.user.id=7 req.user.name="tom cat"`) {
+		t.Errorf("unexpected message: %s", formatted)
+	}
+	if !strings.Contains(msgs[0].targetPoint, "logger_slog_test.go") {
+		t.Errorf("logging point should be the slog caller, got: %s", msgs[0].targetPoint)
+	}
+	if msgs[1].msgLevel != FATAL {
+		t.Errorf("expected FATAL level, got %s", msgs[1].msgLevel)
+	}
+}
@@ -0,0 +1,105 @@
+package loglet
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+/**
+ * 经由标准库io.Writer消费者（log.Logger、fmt.Fprintf等）写入时需要越过的中转包
+ */
+var stdBridgePkgs = []string{"log.", "fmt.", "io.", "bufio."}
+
+/**
+ * 创建一个标准库*log.Logger，其输出的每一行都会成为一条指定级别的日志
+ * 时间、代码点等信息由loglet负责，因此不设置log.Logger的前缀和flag
+ */
+func (logger *Logger) StdLogger(level string) *log.Logger {
+	return log.New(logger.Writer(level), "", 0)
+}
+
+/**
+ * 创建一个io.Writer，写入的内容按行缓冲，每一个完整的行成为一条指定级别的日志；
+ * 不再使用时调用Close，将末尾不足一行的内容作为最后一条日志输出
+ */
+func (logger *Logger) Writer(level string) io.WriteCloser {
+	writer := &lineWriter{logger: &logger.loggerBase}
+	writer.levelNum = logger.getLogLevelNum(level)
+	if writer.levelNum < 0 {
+		printError("unknown log level for writer: %s. use default: INFO", level)
+		writer.level, writer.levelNum = INFO, INFO_LEVEL
+	} else {
+		writer.level = strings.ToUpper(level)
+	}
+	return writer
+}
+
+/**
+ * 按行缓冲的日志写入器，不足一行的内容保留到下次写入
+ */
+type lineWriter struct {
+	sync.Mutex
+	logger   *loggerBase
+	level    string
+	levelNum int
+	lineBuf  []byte
+}
+
+/**
+ * 接收写入内容，将其中完整的行转换为日志
+ */
+func (writer *lineWriter) Write(p []byte) (int, error) {
+	writer.Lock()
+	defer writer.Unlock()
+	writer.lineBuf = append(writer.lineBuf, p...)
+	for {
+		lineEnd := bytes.IndexByte(writer.lineBuf, '\n')
+		if lineEnd < 0 {
+			break
+		}
+		line := bytes.TrimSuffix(writer.lineBuf[:lineEnd], []byte("\r"))
+		writer.writeLine(string(line))
+		writer.lineBuf = writer.lineBuf[lineEnd+1:]
+	}
+	//缓冲区已全部消费时释放底层数组，避免长期持有大块内存
+	if len(writer.lineBuf) == 0 {
+		writer.lineBuf = nil
+	}
+	return len(p), nil
+}
+
+/**
+ * 将缓冲区中不足一行的内容作为一条日志输出
+ */
+func (writer *lineWriter) Flush() error {
+	writer.Lock()
+	defer writer.Unlock()
+	if len(writer.lineBuf) > 0 {
+		writer.writeLine(string(bytes.TrimSuffix(writer.lineBuf, []byte("\r"))))
+		writer.lineBuf = nil
+	}
+	return nil
+}
+
+/**
+ * 关闭写入器，输出缓冲区中剩余的内容
+ */
+func (writer *lineWriter) Close() error {
+	return writer.Flush()
+}
+
+/**
+ * 将一行内容作为日志输出（内容不作为格式化模板，避免其中的%被误解析）
+ */
+func (writer *lineWriter) writeLine(line string) {
+	if writer.levelNum < writer.logger.enabledLevel {
+		return
+	}
+	msg := &LogMsg{msgLevel: writer.level, msgTime: time.Now(), msgContent: line}
+	msg.targetPoint = getLoggingPoint(writer.logger.logPositionOffset, stdBridgePkgs...)
+	writer.logger.emitLog(msg)
+}
@@ -1,6 +1,9 @@
 package loglet
 
 import (
+	"errors"
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 )
@@ -42,3 +45,79 @@ func TestConcurrentLog(t *testing.T) {
 
 	time.Sleep(time.Second * 10)
 }
+
+/**
+ * 测试用的日志书写器，同步记录收到的日志
+ */
+type captureWriter struct {
+	sync.Mutex
+	msgs []*LogMsg
+	err  error //非nil时记录日志后返回该错误，用于模拟写入失败
+}
+
+func (logger *captureWriter) WriteLog(msg *LogMsg) error {
+	logger.Lock()
+	defer logger.Unlock()
+	logger.msgs = append(logger.msgs, msg)
+	return logger.err
+}
+
+func (logger *captureWriter) Close() error {
+	return nil
+}
+
+func (logger *captureWriter) messages() []*LogMsg {
+	logger.Lock()
+	defer logger.Unlock()
+	return append([]*LogMsg(nil), logger.msgs...)
+}
+
+func TestDefaultLogger(t *testing.T) {
+	origin := Default()
+	defer SetDefault(origin)
+
+	logger := NewLogger()
+	writer := new(captureWriter)
+	logger.RegisterWriter("capture", writer)
+	SetDefault(logger)
+	SetDefault(nil)
+	if Default() != logger {
+		t.Fatal("default logger should not be replaced by nil")
+	}
+
+	Info("hello %s", "default")
+	Error(errors.New("boom"))
+	msgs := writer.messages()
+	if len(msgs) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(msgs))
+	}
+	if msgs[0].msgContent != "hello default" || msgs[1].msgContent != "boom" {
+		t.Errorf("unexpected contents: %q, %q", msgs[0].msgContent, msgs[1].msgContent)
+	}
+}
+
+func TestStdLogger(t *testing.T) {
+	logger := NewLogger()
+	writer := new(captureWriter)
+	logger.RegisterWriter("capture", writer)
+
+	logger.StdLogger("warn").Printf("disk usage %d%%", 90)
+	lineWriter := logger.Writer("error")
+	fmt.Fprint(lineWriter, "partial ")
+	fmt.Fprint(lineWriter, "line\r\nsecond line\nremain")
+	if len(writer.messages()) != 3 {
+		t.Fatalf("partial line should stay buffered until close")
+	}
+	lineWriter.Close()
+
+	msgs := writer.messages()
+	if len(msgs) != 4 || msgs[3].msgContent != "remain" {
+		t.Fatalf("expected 4 messages ending with the flushed partial line, got %d", len(msgs))
+	}
+	if msgs[0].msgLevel != WARN || msgs[0].msgContent != "disk usage 90%" {
+		t.Errorf("unexpected message: %s", msgs[0].getFormattedMsg())
+	}
+	if msgs[1].msgLevel != ERROR || msgs[1].msgContent != "partial line" || msgs[2].msgContent != "second line" {
+		t.Errorf("unexpected messages: %q, %q", msgs[1].msgContent, msgs[2].msgContent)
+	}
+}
@@ -0,0 +1,194 @@
+/**
+ * logtest提供在单元测试中断言日志输出的工具：同步记录日志的书写器以及常用的断言方法
+ */
+package logtest
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/duhaifeng/loglet"
+)
+
+/**
+ * 创建测试日志实例时的可选配置
+ */
+type Options struct {
+	Level   string //日志输出级别，默认debug
+	TestLog bool   //是否同时将日志输出到t.Log，这样日志只在测试失败（或-v）时显示
+}
+
+/**
+ * 创建一个只向Recorder输出的日志实例，测试结束时自动关闭，opts可以为nil
+ */
+func NewLogger(t testing.TB, opts *Options) (*loglet.Logger, *Recorder) {
+	recorder := NewRecorder()
+	level := "debug"
+	if opts != nil {
+		if opts.Level != "" {
+			level = opts.Level
+		}
+		if opts.TestLog {
+			recorder.t = t
+		}
+	}
+	logger := loglet.NewLogger()
+	logger.CloseWriters()
+	logger.SetLogLevel(level)
+	logger.RegisterWriter("logtest", recorder)
+	t.Cleanup(func() {
+		logger.CloseWriters()
+	})
+	return logger, recorder
+}
+
+/**
+ * 同步记录日志的书写器，WriteLog返回时日志已记录完毕，断言前不需要等待
+ */
+type Recorder struct {
+	sync.Mutex
+	msgs []*loglet.LogMsg
+	t    testing.TB //非nil时同时将日志输出到t.Log
+}
+
+/**
+ * 创建一个日志记录器
+ */
+func NewRecorder() *Recorder {
+	return new(Recorder)
+}
+
+/**
+ * 记录一条日志
+ */
+func (recorder *Recorder) WriteLog(msg *loglet.LogMsg) error {
+	recorder.Lock()
+	defer recorder.Unlock()
+	recorder.msgs = append(recorder.msgs, msg)
+	if recorder.t != nil {
+		recorder.t.Log(formatTestLog(msg))
+	}
+	return nil
+}
+
+/**
+ * 关闭记录器，之后不再输出到t.Log（测试结束后调用t.Log会引发panic）
+ */
+func (recorder *Recorder) Close() error {
+	recorder.Lock()
+	defer recorder.Unlock()
+	recorder.t = nil
+	return nil
+}
+
+/**
+ * 获取已记录的全部日志
+ */
+func (recorder *Recorder) Messages() []*loglet.LogMsg {
+	recorder.Lock()
+	defer recorder.Unlock()
+	return append([]*loglet.LogMsg(nil), recorder.msgs...)
+}
+
+/**
+ * 清空已记录的日志
+ */
+func (recorder *Recorder) Reset() {
+	recorder.Lock()
+	defer recorder.Unlock()
+	recorder.msgs = nil
+}
+
+/**
+ * 查找指定级别且内容包含substring的日志，level为空时匹配任意级别
+ */
+func (recorder *Recorder) Find(level string, substring string) []*loglet.LogMsg {
+	level = strings.ToUpper(level)
+	var result []*loglet.LogMsg
+	for _, msg := range recorder.Messages() {
+		if level != "" && msg.Level() != level {
+			continue
+		}
+		if strings.Contains(msg.Content(), substring) {
+			result = append(result, msg)
+		}
+	}
+	return result
+}
+
+/**
+ * 断言记录过指定级别且内容包含substring的日志
+ */
+func (recorder *Recorder) AssertLogged(t testing.TB, level string, substring string) bool {
+	t.Helper()
+	if len(recorder.Find(level, substring)) > 0 {
+		return true
+	}
+	t.Errorf("expected a %s log containing %q, got:\n%s", level, substring, recorder.dump())
+	return false
+}
+
+/**
+ * 断言没有记录过指定级别且内容包含substring的日志
+ */
+func (recorder *Recorder) AssertNotLogged(t testing.TB, level string, substring string) bool {
+	t.Helper()
+	found := recorder.Find(level, substring)
+	if len(found) == 0 {
+		return true
+	}
+	t.Errorf("unexpected %s log containing %q:\n%s", level, substring, formatMsg(found[0]))
+	return false
+}
+
+/**
+ * 要求没有记录过ERROR及FATAL级别的日志，否则立即终止测试
+ */
+func (recorder *Recorder) RequireNoErrors(t testing.TB) {
+	t.Helper()
+	var errorLogs []string
+	for _, msg := range recorder.Messages() {
+		if msg.Level() == loglet.ERROR || msg.Level() == loglet.FATAL {
+			errorLogs = append(errorLogs, formatMsg(msg))
+		}
+	}
+	if len(errorLogs) > 0 {
+		t.Fatalf("expected no error logs, got %d:\n%s", len(errorLogs), strings.Join(errorLogs, "\n"))
+	}
+}
+
+/**
+ * 输出全部已记录的日志，用于断言失败时展示
+ */
+func (recorder *Recorder) dump() string {
+	msgs := recorder.Messages()
+	if len(msgs) == 0 {
+		return "(no logs)"
+	}
+	lines := make([]string, len(msgs))
+	for i, msg := range msgs {
+		lines[i] = formatMsg(msg)
+	}
+	return strings.Join(lines, "\n")
+}
+
+/**
+ * 输出到t.Log的日志以真实的日志记录点（文件:行号）开头，
+ * t.Log自身标注的位置是Recorder内部，而日志模块的调用栈无法全部标记为t.Helper
+ */
+func formatTestLog(msg *loglet.LogMsg) string {
+	//日志记录点的格式为"文件 行号 函数() [协程号]"
+	point := strings.SplitN(msg.TargetPoint(), " ", 3)
+	if len(point) < 2 {
+		return formatMsg(msg)
+	}
+	return point[0] + ":" + point[1] + ": " + formatMsg(msg)
+}
+
+/**
+ * 将日志格式化为一行文本
+ */
+func formatMsg(msg *loglet.LogMsg) string {
+	return strings.TrimSuffix(string(new(loglet.TextFormatter).Format(msg)), "\n")
+}
@@ -0,0 +1,74 @@
+package logtest
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/duhaifeng/loglet"
+)
+
+/**
+ * 用于验证断言失败行为的测试对象
+ */
+type fakeT struct {
+	testing.TB
+	failures []string
+	fatal    bool
+	logs     []string
+}
+
+func (t *fakeT) Helper() {
+}
+
+func (t *fakeT) Errorf(format string, args ...interface{}) {
+	t.failures = append(t.failures, fmt.Sprintf(format, args...))
+}
+
+func (t *fakeT) Log(args ...interface{}) {
+	t.logs = append(t.logs, fmt.Sprint(args...))
+}
+
+func (t *fakeT) Fatalf(format string, args ...interface{}) {
+	t.Errorf(format, args...)
+	t.fatal = true
+}
+
+func TestRecorder(t *testing.T) {
+	logger, recorder := NewLogger(t, &Options{Level: "info", TestLog: true})
+	logger.Debug("filtered")
+	logger.Info("user %s logged in", "tom")
+	logger.Error(errors.New("connection refused"))
+
+	recorder.AssertLogged(t, "info", "logged in")
+	recorder.AssertNotLogged(t, "", "filtered")
+	fake := new(fakeT)
+	if recorder.AssertLogged(fake, "warn", "logged in") || len(fake.failures) != 1 {
+		t.Error("AssertLogged should fail for missing log")
+	}
+	recorder.RequireNoErrors(fake)
+	if !fake.fatal {
+		t.Error("RequireNoErrors should fail when errors were logged")
+	}
+	if len(recorder.Find("error", "refused")) != 1 {
+		t.Error("error log should be recorded")
+	}
+	recorder.Reset()
+	recorder.RequireNoErrors(t)
+}
+
+func TestRecorderTestLogCaller(t *testing.T) {
+	fake := new(fakeT)
+	recorder := NewRecorder()
+	recorder.t = fake
+	logger := loglet.NewLogger()
+	logger.CloseWriters()
+	logger.RegisterWriter("logtest", recorder)
+	logger.Info("hello")
+	logger.CloseWriters()
+	//t.Log标注的是Recorder内部的位置，日志内容需要以真实的调用点开头
+	if len(fake.logs) != 1 || !strings.HasPrefix(fake.logs[0], "logtest_test.go:") {
+		t.Errorf("test log should start with the logging point: %v", fake.logs)
+	}
+}
@@ -0,0 +1,236 @@
+package loglet
+
+import (
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+/**
+ * 书写器耗时直方图的桶上界（秒）
+ */
+var latencyBuckets = []float64{0.00001, 0.0001, 0.001, 0.01, 0.1, 1}
+
+/**
+ * 日志实例的统计信息
+ */
+type LoggerStats struct {
+	Levels  map[string]uint64      `json:"levels"`  //各级别被接收（达到输出级别）的日志条数
+	Writers map[string]WriterStats `json:"writers"` //各书写器的统计信息
+}
+
+/**
+ * 单个书写器的统计信息
+ */
+type WriterStats struct {
+	Messages      uint64            `json:"messages"`       //分发给书写器的日志条数
+	Bytes         uint64            `json:"bytes"`          //书写器实际写出的字节数
+	Drops         uint64            `json:"drops"`          //被丢弃的日志条数
+	Errors        uint64            `json:"errors"`         //写入失败次数
+	Rotations     uint64            `json:"rotations"`      //日志文件滚动次数
+	Deletions     uint64            `json:"deletions"`      //清理的历史日志文件个数
+	QueueDepth    int               `json:"queue_depth"`    //缓存管道中等待写入的日志条数
+	QueueCapacity int               `json:"queue_capacity"` //缓存管道的容量
+	Degraded      bool              `json:"degraded"`       //书写器是否处于降级状态（例如磁盘已满时文件无法写入）
+	Latency       HistogramSnapshot `json:"latency"`        //分发给书写器时调用WriteLog的耗时
+}
+
+/**
+ * 书写器可选实现的统计接口，用于上报字节数、丢弃、错误、滚动等只有书写器自身知道的信息
+ */
+type StatsWriter interface {
+	WriterStats() WriterStats
+}
+
+/**
+ * 耗时直方图快照
+ */
+type HistogramSnapshot struct {
+	Buckets []float64 `json:"buckets"` //桶上界（秒）
+	Counts  []uint64  `json:"counts"`  //落在各桶上界以内的累计次数
+	Count   uint64    `json:"count"`   //总次数
+	Sum     float64   `json:"sum"`     //总耗时（秒）
+}
+
+/**
+ * 书写器通用计数器，各字段通过atomic操作读写（需位于结构体开头，保证32位平台上的对齐）
+ */
+type writerCounters struct {
+	messages  uint64
+	bytes     uint64
+	drops     uint64
+	errors    uint64
+	rotations uint64
+	deletions uint64
+}
+
+/**
+ * 将计数器转换为统计信息
+ */
+func (counters *writerCounters) getStats() WriterStats {
+	return WriterStats{
+		Messages:  atomic.LoadUint64(&counters.messages),
+		Bytes:     atomic.LoadUint64(&counters.bytes),
+		Drops:     atomic.LoadUint64(&counters.drops),
+		Errors:    atomic.LoadUint64(&counters.errors),
+		Rotations: atomic.LoadUint64(&counters.rotations),
+		Deletions: atomic.LoadUint64(&counters.deletions),
+	}
+}
+
+/**
+ * 日志实例记录的单个书写器指标
+ */
+type writerMetrics struct {
+	messages uint64
+	latency  latencyHistogram
+}
+
+/**
+ * 耗时直方图，各字段通过atomic操作读写
+ */
+type latencyHistogram struct {
+	count   uint64
+	sumNano uint64
+	buckets [7]uint64 //最后一个桶为+Inf
+}
+
+/**
+ * 记录一次耗时
+ */
+func (histogram *latencyHistogram) observe(duration time.Duration) {
+	seconds := duration.Seconds()
+	bucket := len(latencyBuckets)
+	for i, upperBound := range latencyBuckets {
+		if seconds <= upperBound {
+			bucket = i
+			break
+		}
+	}
+	atomic.AddUint64(&histogram.buckets[bucket], 1)
+	atomic.AddUint64(&histogram.sumNano, uint64(duration))
+	atomic.AddUint64(&histogram.count, 1)
+}
+
+/**
+ * 获取直方图快照（各桶次数为累计值）
+ */
+func (histogram *latencyHistogram) snapshot() HistogramSnapshot {
+	snapshot := HistogramSnapshot{Buckets: latencyBuckets, Counts: make([]uint64, len(latencyBuckets))}
+	var cumulative uint64
+	for i := range latencyBuckets {
+		cumulative += atomic.LoadUint64(&histogram.buckets[i])
+		snapshot.Counts[i] = cumulative
+	}
+	snapshot.Count = atomic.LoadUint64(&histogram.count)
+	snapshot.Sum = time.Duration(atomic.LoadUint64(&histogram.sumNano)).Seconds()
+	return snapshot
+}
+
+/**
+ * 获取日志实例的统计信息
+ */
+func (logger *loggerBase) Stats() LoggerStats {
+	stats := LoggerStats{Levels: make(map[string]uint64), Writers: make(map[string]WriterStats)}
+	for levelNum, level := range []string{DEBUG, INFO, WARN, ERROR, FATAL} {
+		stats.Levels[level] = atomic.LoadUint64(&logger.levelCounts[levelNum])
+	}
+	for name, logWriter := range logger.logWriters {
+		var writerStats WriterStats
+		if statsWriter, ok := logWriter.(StatsWriter); ok {
+			writerStats = statsWriter.WriterStats()
+		}
+		if metrics, ok := logger.writerMetrics[name]; ok {
+			writerStats.Messages = atomic.LoadUint64(&metrics.messages)
+			writerStats.Latency = metrics.latency.snapshot()
+		}
+		stats.Writers[name] = writerStats
+	}
+	return stats
+}
+
+/**
+ * 通过expvar发布统计信息（名称重复时expvar会panic，同一名称只能发布一次）
+ */
+func (logger *loggerBase) PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return logger.Stats()
+	}))
+}
+
+/**
+ * 创建一个以Prometheus文本格式输出统计信息的http.Handler
+ */
+func (logger *loggerBase) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		writePrometheusMetrics(w, logger.Stats())
+	})
+}
+
+/**
+ * 按Prometheus文本格式输出统计信息
+ */
+func writePrometheusMetrics(w io.Writer, stats LoggerStats) {
+	writeMetricHeader(w, "loglet_messages_total", "counter", "Log messages accepted per level.")
+	for _, level := range []string{DEBUG, INFO, WARN, ERROR, FATAL} {
+		fmt.Fprintf(w, "loglet_messages_total{level=%q} %d\n", level, stats.Levels[level])
+	}
+	writerNames := make([]string, 0, len(stats.Writers))
+	for name := range stats.Writers {
+		writerNames = append(writerNames, name)
+	}
+	sort.Strings(writerNames)
+	writerCounters := []struct {
+		name     string
+		help     string
+		getValue func(writerStats WriterStats) uint64
+	}{
+		{"loglet_writer_messages_total", "Log messages dispatched to the writer.", func(s WriterStats) uint64 { return s.Messages }},
+		{"loglet_writer_bytes_total", "Bytes written by the writer.", func(s WriterStats) uint64 { return s.Bytes }},
+		{"loglet_writer_drops_total", "Log messages dropped by the writer.", func(s WriterStats) uint64 { return s.Drops }},
+		{"loglet_writer_errors_total", "Write errors of the writer.", func(s WriterStats) uint64 { return s.Errors }},
+		{"loglet_writer_rotations_total", "Log file rotations.", func(s WriterStats) uint64 { return s.Rotations }},
+		{"loglet_writer_deletions_total", "Expired log files deleted.", func(s WriterStats) uint64 { return s.Deletions }},
+	}
+	for _, counter := range writerCounters {
+		writeMetricHeader(w, counter.name, "counter", counter.help)
+		for _, name := range writerNames {
+			fmt.Fprintf(w, "%s{writer=%q} %d\n", counter.name, name, counter.getValue(stats.Writers[name]))
+		}
+	}
+	writeMetricHeader(w, "loglet_writer_queue_depth", "gauge", "Log messages buffered and waiting to be written.")
+	for _, name := range writerNames {
+		fmt.Fprintf(w, "loglet_writer_queue_depth{writer=%q} %d\n", name, stats.Writers[name].QueueDepth)
+	}
+	writeMetricHeader(w, "loglet_writer_degraded", "gauge", "Whether the writer is degraded (1) because its output is not writable.")
+	for _, name := range writerNames {
+		degraded := 0
+		if stats.Writers[name].Degraded {
+			degraded = 1
+		}
+		fmt.Fprintf(w, "loglet_writer_degraded{writer=%q} %d\n", name, degraded)
+	}
+	writeMetricHeader(w, "loglet_writer_latency_seconds", "histogram", "Time spent in the writer's WriteLog call.")
+	for _, name := range writerNames {
+		latency := stats.Writers[name].Latency
+		for i, upperBound := range latency.Buckets {
+			fmt.Fprintf(w, "loglet_writer_latency_seconds_bucket{writer=%q,le=%q} %d\n", name, strconv.FormatFloat(upperBound, 'g', -1, 64), latency.Counts[i])
+		}
+		fmt.Fprintf(w, "loglet_writer_latency_seconds_bucket{writer=%q,le=\"+Inf\"} %d\n", name, latency.Count)
+		fmt.Fprintf(w, "loglet_writer_latency_seconds_sum{writer=%q} %s\n", name, strconv.FormatFloat(latency.Sum, 'g', -1, 64))
+		fmt.Fprintf(w, "loglet_writer_latency_seconds_count{writer=%q} %d\n", name, latency.Count)
+	}
+}
+
+/**
+ * 输出指标的HELP和TYPE说明
+ */
+func writeMetricHeader(w io.Writer, name string, metricType string, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+}
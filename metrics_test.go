@@ -0,0 +1,47 @@
+package loglet
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoggerStats(t *testing.T) {
+	logger := NewLogger()
+	logger.CloseWriters()
+	logger.SetLogLevel("info")
+	fileWriter := new(FileWriter)
+	fileWriter.SetFileBaseName(filepath.Join(t.TempDir(), "stats.log"))
+	asyncWriter := NewAsyncWriter(fileWriter, nil)
+	logger.RegisterWriter("file", asyncWriter)
+	logger.RegisterWriter("ring", NewRingWriter(nil))
+	logger.Debug("filtered")
+	logger.Info("info")
+	logger.Error("error")
+	asyncWriter.Flush()
+
+	stats := logger.Stats()
+	if stats.Levels[DEBUG] != 0 || stats.Levels[INFO] != 1 || stats.Levels[ERROR] != 1 {
+		t.Errorf("unexpected level counts: %v", stats.Levels)
+	}
+	fileStats := stats.Writers["file"]
+	if fileStats.Messages != 2 || fileStats.Bytes == 0 || fileStats.Latency.Count != 2 || fileStats.QueueCapacity != 10000 {
+		t.Errorf("unexpected file writer stats: %+v", fileStats)
+	}
+
+	recorder := httptest.NewRecorder()
+	logger.MetricsHandler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := ioutil.ReadAll(recorder.Body)
+	for _, expected := range []string{
+		`loglet_messages_total{level="INFO"} 1`,
+		`loglet_writer_messages_total{writer="ring"} 2`,
+		`loglet_writer_latency_seconds_bucket{writer="file",le="+Inf"} 2`,
+		"# TYPE loglet_writer_queue_depth gauge",
+	} {
+		if !strings.Contains(string(body), expected) {
+			t.Errorf("metrics output should contain %q:\n%s", expected, body)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package loglet
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"time"
+)
+
+/**
+ * 日志行不符合loglet文本格式时返回的错误
+ */
+var ErrInvalidLine = errors.New("not a loglet log line")
+
+/**
+ * 解析一行由LogMsg.getFormattedMsg生成的文本日志：
+ * 2006-01-02 15:04:05.000 file line func() [goroutine] [LEVEL] message
+ * 时间按本地时区解析；附加字段作为消息内容的一部分保留，保证重新格式化后与原文一致
+ */
+func ParseLine(line []byte) (*LogMsg, error) {
+	line = bytes.TrimSuffix(bytes.TrimSuffix(line, []byte("\n")), []byte("\r"))
+	timeLen := len(textTimeLayout)
+	if len(line) <= timeLen || line[timeLen] != ' ' {
+		return nil, ErrInvalidLine
+	}
+	msgTime, err := time.ParseInLocation(textTimeLayout, string(line[:timeLen]), time.Local)
+	if err != nil {
+		return nil, ErrInvalidLine
+	}
+	rest := line[timeLen+1:]
+	//代码点以"[goroutine号]"结尾，其后紧跟级别；消息内容中也可能出现级别标记，因此取最靠前的一个
+	pointEnd, msgLevel := -1, ""
+	for _, level := range []string{DEBUG, INFO, WARN, ERROR, FATAL} {
+		levelIndex := bytes.Index(rest, []byte("] ["+level+"]"))
+		if levelIndex >= 0 && (pointEnd < 0 || levelIndex < pointEnd) {
+			pointEnd, msgLevel = levelIndex, level
+		}
+	}
+	if pointEnd < 0 {
+		return nil, ErrInvalidLine
+	}
+	msg := &LogMsg{msgLevel: msgLevel, msgTime: msgTime, targetPoint: string(rest[:pointEnd+1])}
+	contentStart := pointEnd + len("] ["+msgLevel+"] ")
+	if contentStart < len(rest) {
+		msg.msgContent = string(rest[contentStart:])
+	}
+	return msg, nil
+}
+
+/**
+ * 解析一行由JSONFormatter生成的JSON日志，时间转换为本地时区（与文本日志一致），附加字段按键名排序
+ */
+func ParseJSONLine(line []byte) (*LogMsg, error) {
+	var jsonMsg jsonLogMsg
+	if err := json.Unmarshal(line, &jsonMsg); err != nil {
+		return nil, err
+	}
+	msgTime, err := time.Parse(jsonTimeLayout, jsonMsg.Time)
+	if err != nil {
+		return nil, err
+	}
+	msg := &LogMsg{msgLevel: jsonMsg.Level, msgTime: msgTime.Local(), targetPoint: jsonMsg.Caller, msgContent: jsonMsg.Msg, loggerName: jsonMsg.Logger}
+	if len(jsonMsg.Fields) > 0 {
+		keys := make([]string, 0, len(jsonMsg.Fields))
+		for key := range jsonMsg.Fields {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			msg.fields = append(msg.fields, Field{Key: key, Value: jsonMsg.Fields[key]})
+		}
+	}
+	return msg, nil
+}
+
+/**
+ * 日志流式读取器（支持文本及JSON格式），多行日志（例如带堆栈的消息）的后续行会合并到上一条日志的内容中
+ */
+type Reader struct {
+	scanner *bufio.Scanner
+	next    *LogMsg //已读取了首行、尚未返回的日志
+}
+
+/**
+ * 创建一个日志读取器
+ */
+func NewReader(r io.Reader) *Reader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	return &Reader{scanner: scanner}
+}
+
+/**
+ * 读取下一条日志，读取完毕时返回io.EOF
+ * 以"{"开头的行按JSON格式解析；文件开头不属于任何日志的行会合并为一条只有内容的日志返回
+ */
+func (reader *Reader) Read() (*LogMsg, error) {
+	for reader.scanner.Scan() {
+		line := reader.scanner.Bytes()
+		msg, err := ParseLine(line)
+		if err != nil && bytes.HasPrefix(line, []byte("{")) {
+			msg, err = ParseJSONLine(line)
+		}
+		if err != nil {
+			line = bytes.TrimSuffix(line, []byte("\r"))
+			if reader.next == nil {
+				reader.next = &LogMsg{msgContent: string(line)}
+			} else {
+				reader.next.msgContent += "\n" + string(line)
+			}
+			continue
+		}
+		prevMsg := reader.next
+		reader.next = msg
+		if prevMsg != nil {
+			return prevMsg, nil
+		}
+	}
+	if err := reader.scanner.Err(); err != nil {
+		return nil, err
+	}
+	if reader.next != nil {
+		msg := reader.next
+		reader.next = nil
+		return msg, nil
+	}
+	return nil, io.EOF
+}
@@ -0,0 +1,72 @@
+package loglet
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseLineRoundTrip(t *testing.T) {
+	msg := &LogMsg{msgLevel: WARN, msgTime: time.Now().Truncate(time.Millisecond), targetPoint: getLoggingPoint(0), msgContent: "content with [INFO] inside",
+		fields: []Field{{Key: "k", Value: "v w"}}}
+	formatted := msg.getFormattedMsg()
+	parsed, err := ParseLine([]byte(formatted + "\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.getFormattedMsg() != formatted {
+		t.Errorf("round trip mismatch:\n%s\n%s", formatted, parsed.getFormattedMsg())
+	}
+	if parsed.Level() != WARN || !parsed.Time().Equal(msg.msgTime) || parsed.TargetPoint() != msg.targetPoint {
+		t.Errorf("unexpected parsed message: %+v", parsed)
+	}
+	if _, err = ParseLine([]byte("\tat stack line")); err != ErrInvalidLine {
+		t.Errorf("expected ErrInvalidLine, got %v", err)
+	}
+}
+
+func TestReader(t *testing.T) {
+	content := "orphan line\n" +
+		"2024-01-01 10:00:00.000 a.go 1 main.main() [1] [ERROR] panic\r\n" +
+		"goroutine 1 [running]:\n" +
+		"2024-01-01 10:00:01.000 a.go 2 main.main() [1] [INFO] \n" +
+		"2024-01-01 10:00:02.000 a.go 3 main.main() [1] [DEBUG] last"
+	reader := NewReader(strings.NewReader(content))
+	var msgs []*LogMsg
+	for {
+		msg, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		msgs = append(msgs, msg)
+	}
+	if len(msgs) != 4 {
+		t.Fatalf("expected 4 messages, got %d", len(msgs))
+	}
+	if msgs[0].Level() != "" || msgs[0].Content() != "orphan line" {
+		t.Errorf("unexpected orphan message: %+v", msgs[0])
+	}
+	if msgs[1].Level() != ERROR || msgs[1].Content() != "panic\ngoroutine 1 [running]:" {
+		t.Errorf("unexpected multi-line message: %q", msgs[1].Content())
+	}
+	if msgs[2].Content() != "" || msgs[3].Content() != "last" {
+		t.Errorf("unexpected messages: %q, %q", msgs[2].Content(), msgs[3].Content())
+	}
+}
+
+func TestParseJSONLine(t *testing.T) {
+	msg := &LogMsg{msgLevel: INFO, msgTime: time.Now().Truncate(time.Millisecond), targetPoint: "a.go 1 main.main() [1]", msgContent: "hello",
+		fields: []Field{{Key: "b", Value: "x"}, {Key: "a", Value: true}}}
+	reader := NewReader(strings.NewReader(string(new(JSONFormatter).Format(msg))))
+	parsed, err := reader.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !parsed.Time().Equal(msg.msgTime) || parsed.getFormattedMsg() != msg.msgTime.Format(textTimeLayout)+` a.go 1 main.main() [1] [INFO] hello a=true b=x` {
+		t.Errorf("unexpected parsed message: %s", parsed.getFormattedMsg())
+	}
+}
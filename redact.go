@@ -0,0 +1,310 @@
+package loglet
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+/**
+ * 敏感信息的处理方式
+ */
+const (
+	REDACT_MASK = "mask" //替换为****
+	REDACT_HASH = "hash" //替换为哈希值，便于在不暴露原值的情况下关联同一个值
+	REDACT_DROP = "drop" //直接删除（字段则整个删除）
+)
+
+/**
+ * 内置的敏感信息检测规则
+ */
+var builtinRedactRules = map[string]func() *redactRule{
+	"card": func() *redactRule {
+		return &redactRule{name: "card", regex: regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`), validate: isLuhnValid}
+	},
+	"jwt": func() *redactRule {
+		return &redactRule{name: "jwt", regex: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`)}
+	},
+	"bearer": func() *redactRule {
+		//只处理令牌部分，保留Bearer前缀
+		return &redactRule{name: "bearer", regex: regexp.MustCompile(`(?i)\bbearer\s+([A-Za-z0-9\-._~+/]+=*)`)}
+	},
+	"email": func() *redactRule {
+		return &redactRule{name: "email", regex: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)}
+	},
+	"ip": func() *redactRule {
+		return &redactRule{name: "ip", regex: regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b|(?:[0-9A-Fa-f]{0,4}:){2,7}[0-9A-Fa-f]{0,4}`), validate: isIPAddress, isolated: true}
+	},
+}
+
+/**
+ * 敏感信息检测规则
+ */
+type redactRule struct {
+	name     string
+	regex    *regexp.Regexp //包含捕获分组时只处理第一个分组的内容
+	validate func(match string) bool
+	isolated bool //匹配内容前后不能紧邻字母、数字或冒号，避免从"std::vector"之类的文本中截取
+	action   string
+}
+
+/**
+ * 敏感信息脱敏处理器，作用于日志内容及字符串类型的字段值，并按字段名屏蔽字段
+ * 脱敏在钩子之后、分发到书写器之前执行，因此对控制台、文件等所有书写器一致生效
+ */
+type Redactor struct {
+	rules        []*redactRule
+	fieldActions map[string]string //小写的字段名 -> 处理方式
+}
+
+/**
+ * 创建一个不包含任何规则的脱敏处理器
+ */
+func NewRedactor() *Redactor {
+	return &Redactor{fieldActions: make(map[string]string)}
+}
+
+/**
+ * 添加一个内置检测规则：card（通过Luhn校验的银行卡号）、jwt、bearer、email、ip
+ */
+func (redactor *Redactor) AddDetector(name string, action string) error {
+	newRule, ok := builtinRedactRules[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return fmt.Errorf("unknown redact detector: %s", name)
+	}
+	rule := newRule()
+	rule.action = action
+	return redactor.addRule(rule)
+}
+
+/**
+ * 添加一个自定义正则检测规则，正则中包含捕获分组时只处理第一个分组的内容
+ */
+func (redactor *Redactor) AddPattern(name string, pattern string, action string) error {
+	regex, err := regexp.Compile(pattern)
+	if err != nil {
+		return err
+	}
+	return redactor.addRule(&redactRule{name: name, regex: regex, action: action})
+}
+
+/**
+ * 按字段名屏蔽字段（不区分大小写，分组字段按最后一级名称匹配）
+ */
+func (redactor *Redactor) DenyFields(action string, names ...string) error {
+	if err := checkRedactAction(action); err != nil {
+		return err
+	}
+	for _, name := range names {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			redactor.fieldActions[name] = action
+		}
+	}
+	return nil
+}
+
+/**
+ * 添加检测规则
+ */
+func (redactor *Redactor) addRule(rule *redactRule) error {
+	if err := checkRedactAction(rule.action); err != nil {
+		return err
+	}
+	redactor.rules = append(redactor.rules, rule)
+	return nil
+}
+
+/**
+ * 对日志内容及字段进行脱敏
+ */
+func (redactor *Redactor) redactMsg(msg *LogMsg) {
+	msg.msgContent = redactor.Redact(msg.msgContent)
+	fields := msg.fields[:0]
+	for _, field := range msg.fields {
+		key := strings.ToLower(field.Key)
+		action, denied := redactor.fieldActions[key[strings.LastIndex(key, ".")+1:]]
+		if denied {
+			if action == REDACT_DROP {
+				continue
+			}
+			field.Value = applyRedactAction(action, fmt.Sprint(field.Value))
+		} else if value, ok := field.Value.(string); ok {
+			field.Value = redactor.Redact(value)
+		}
+		fields = append(fields, field)
+	}
+	msg.fields = fields
+}
+
+/**
+ * 按检测规则对文本进行脱敏
+ */
+func (redactor *Redactor) Redact(content string) string {
+	for _, rule := range redactor.rules {
+		content = rule.redact(content)
+	}
+	return content
+}
+
+/**
+ * 按规则处理文本中所有匹配的内容
+ */
+func (rule *redactRule) redact(content string) string {
+	matches := rule.regex.FindAllStringSubmatchIndex(content, -1)
+	if len(matches) == 0 {
+		return content
+	}
+	var buf strings.Builder
+	lastEnd := 0
+	for _, match := range matches {
+		start, end := match[0], match[1]
+		if len(match) >= 4 && match[2] >= 0 {
+			start, end = match[2], match[3]
+		}
+		value := content[start:end]
+		if rule.isolated && (isWordByte(content, start-1) || isWordByte(content, end)) {
+			continue
+		}
+		if rule.validate != nil && !rule.validate(value) {
+			continue
+		}
+		buf.WriteString(content[lastEnd:start])
+		buf.WriteString(applyRedactAction(rule.action, value))
+		lastEnd = end
+	}
+	buf.WriteString(content[lastEnd:])
+	return buf.String()
+}
+
+/**
+ * 判断指定位置是否为字母、数字、下划线或冒号，越界时返回false
+ */
+func isWordByte(content string, index int) bool {
+	if index < 0 || index >= len(content) {
+		return false
+	}
+	char := content[index]
+	return char == '_' || char == ':' || char >= '0' && char <= '9' || char >= 'a' && char <= 'z' || char >= 'A' && char <= 'Z'
+}
+
+/**
+ * 对敏感值执行处理
+ */
+func applyRedactAction(action string, value string) string {
+	switch action {
+	case REDACT_HASH:
+		sum := sha256.Sum256([]byte(value))
+		return "sha256:" + hex.EncodeToString(sum[:])[:16]
+	case REDACT_DROP:
+		return ""
+	default:
+		return "****"
+	}
+}
+
+/**
+ * 检查处理方式是否合法
+ */
+func checkRedactAction(action string) error {
+	switch action {
+	case REDACT_MASK, REDACT_HASH, REDACT_DROP:
+		return nil
+	default:
+		return fmt.Errorf("unknown redact action: %s", action)
+	}
+}
+
+/**
+ * 通过Luhn算法校验银行卡号（忽略其中的空格和短横线）
+ */
+func isLuhnValid(number string) bool {
+	digits := strings.NewReplacer(" ", "", "-", "").Replace(number)
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		digit := int(digits[i] - '0')
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+/**
+ * 判断是否为合法的IP地址（IPv6至少包含一位十六进制数字，避免误伤单独的"::"）
+ */
+func isIPAddress(value string) bool {
+	if net.ParseIP(value) == nil {
+		return false
+	}
+	return !strings.Contains(value, ":") || strings.ContainsAny(value, "0123456789abcdefABCDEF")
+}
+
+/**
+ * 根据Init配置创建脱敏处理器，未配置任何脱敏规则时返回nil：
+ * redact=card,jwt,bearer,email,ip        启用的内置检测规则
+ * redact_action=mask                     默认处理方式（mask、hash、drop）
+ * redact_action.<规则名>=hash             单独指定某个规则的处理方式
+ * redact_pattern.<规则名>=<正则>           自定义检测规则
+ * redact_fields=password,token           按字段名屏蔽的字段
+ * redact_fields_action=drop              字段的处理方式（单独的配置项，避免与名为fields的自定义规则冲突）
+ */
+func newRedactorFromConfig(configs map[string]string) (*Redactor, error) {
+	redactor := NewRedactor()
+	defaultAction := strings.ToLower(configs["redact_action"])
+	if defaultAction == "" {
+		defaultAction = REDACT_MASK
+	}
+	getAction := func(name string) string {
+		if action := configs["redact_action."+name]; action != "" {
+			return strings.ToLower(action)
+		}
+		return defaultAction
+	}
+	for _, name := range strings.Split(configs["redact"], ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		if err := redactor.AddDetector(name, getAction(name)); err != nil {
+			return nil, err
+		}
+	}
+	//自定义规则按名称排序，保证多次初始化时的执行顺序一致
+	var patternNames []string
+	for key := range configs {
+		if strings.HasPrefix(key, "redact_pattern.") {
+			patternNames = append(patternNames, strings.TrimPrefix(key, "redact_pattern."))
+		}
+	}
+	sort.Strings(patternNames)
+	for _, name := range patternNames {
+		if err := redactor.AddPattern(name, configs["redact_pattern."+name], getAction(name)); err != nil {
+			return nil, err
+		}
+	}
+	if fields := configs["redact_fields"]; fields != "" {
+		fieldAction := defaultAction
+		if action := configs["redact_fields_action"]; action != "" {
+			fieldAction = strings.ToLower(action)
+		}
+		if err := redactor.DenyFields(fieldAction, strings.Split(fields, ",")...); err != nil {
+			return nil, err
+		}
+	}
+	if len(redactor.rules) == 0 && len(redactor.fieldActions) == 0 {
+		return nil, nil
+	}
+	return redactor, nil
+}
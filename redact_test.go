@@ -0,0 +1,101 @@
+package loglet
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRedactDetectors(t *testing.T) {
+	redactor := NewRedactor()
+	for _, name := range []string{"card", "jwt", "bearer", "email", "ip"} {
+		if err := redactor.AddDetector(name, REDACT_MASK); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cases := map[string]string{
+		"pay with 4111 1111 1111 1111 ok":              "pay with **** ok",
+		"order 1234567890123 done":                     "order 1234567890123 done",
+		"token eyJhbGciOi.eyJzdWIiOi.c2lnbmF0dXJl end": "token **** end",
+		"Authorization: Bearer abc.DEF-123=":           "Authorization: Bearer ****",
+		"mail to alice@example.com now":                "mail to **** now",
+		"from 192.168.1.10 and ::1 at 12:30:45":        "from **** and **** at 12:30:45",
+		"use std::vector":                              "use std::vector",
+	}
+	for input, expected := range cases {
+		if actual := redactor.Redact(input); actual != expected {
+			t.Errorf("redact %q: expected %q, got %q", input, expected, actual)
+		}
+	}
+}
+
+func TestRedactActions(t *testing.T) {
+	redactor := NewRedactor()
+	if err := redactor.AddPattern("order", `order=(\d+)`, REDACT_HASH); err != nil {
+		t.Fatal(err)
+	}
+	if err := redactor.AddDetector("email", REDACT_DROP); err != nil {
+		t.Fatal(err)
+	}
+	if err := redactor.AddDetector("ssn", REDACT_MASK); err == nil {
+		t.Error("unknown detector should fail")
+	}
+	if err := redactor.AddPattern("bad", `(`, REDACT_MASK); err == nil {
+		t.Error("invalid pattern should fail")
+	}
+	if err := redactor.DenyFields("erase", "password"); err == nil {
+		t.Error("unknown action should fail")
+	}
+	first := redactor.Redact("order=42 <bob@example.com>")
+	second := redactor.Redact("retry order=42")
+	if !strings.HasPrefix(first, "order=sha256:") || !strings.HasSuffix(first, " <>") {
+		t.Fatalf("unexpected redaction: %s", first)
+	}
+	if first[:len(first)-3] != second[len("retry "):] {
+		t.Errorf("hash should be stable: %s / %s", first, second)
+	}
+}
+
+func TestLoggerRedaction(t *testing.T) {
+	logger := NewLogger()
+	logger.Init(map[string]string{
+		"redact":                "card,bearer",
+		"redact_action.bearer":  "hash",
+		"redact_pattern.key":    `key-[0-9a-f]{8}`,
+		"redact_pattern.fields": `tok-[0-9]+`,
+		"redact_action.fields":  "hash",
+		"redact_fields":         "password,Secret",
+		"redact_fields_action":  "drop",
+	})
+	writer := new(captureWriter)
+	logger.CloseWriters()
+	logger.RegisterWriter("capture", writer)
+	logger.AddHook(HookFunc(func(msg *LogMsg) bool {
+		msg.SetField("password", "hunter2")
+		msg.SetField("auth.secret", "s3")
+		msg.SetField("note", "card 4111-1111-1111-1111")
+		return true
+	}))
+
+	logger.Info("login key-deadbeef with Bearer abc123 tok-42")
+
+	msgs := writer.messages()
+	if len(msgs) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(msgs))
+	}
+	content := msgs[0].Content()
+	//名为fields的自定义规则使用自己的处理方式，不影响字段的处理方式
+	if !strings.HasPrefix(content, "login **** with Bearer sha256:") || strings.Contains(content, "tok-42") {
+		t.Errorf("content should be redacted: %s", content)
+	}
+	fields := msgs[0].Fields()
+	if len(fields) != 1 || fields[0].Key != "note" || fields[0].Value != "card ****" {
+		t.Errorf("fields should be redacted: %v", fields)
+	}
+
+	//重新初始化且不配置脱敏时应关闭脱敏
+	logger.Init(map[string]string{})
+	if logger.redactor != nil {
+		t.Error("redactor should be cleared by Init")
+	}
+	logger.CloseWriters()
+}
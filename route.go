@@ -0,0 +1,202 @@
+package loglet
+
+import (
+	"fmt"
+	"strings"
+)
+
+/**
+ * 日志路由规则，将满足条件的日志发送到指定名称的书写器
+ * 各匹配条件之间为"与"的关系，未设置的条件不参与匹配，所有条件均未设置的规则匹配全部日志
+ */
+type Route struct {
+	Name          string            //规则名称，仅用于配置和排查
+	Levels        []string          //匹配的日志级别
+	Loggers       []string          //匹配的日志实例名称
+	CallerPackage string            //匹配调用点所在的包（按前缀匹配，例如github.com/foo/bar）
+	Fields        map[string]string //匹配的字段值，值为"*"时只要求字段存在
+	Writers       []string          //日志发送到的书写器名称
+	Continue      bool              //匹配后是否继续匹配后续规则，默认匹配到第一条规则后即停止
+}
+
+/**
+ * 已注册的路由规则
+ */
+type registeredRoute struct {
+	route   Route
+	levels  map[string]bool //为nil时匹配所有级别
+	loggers map[string]bool //为nil时匹配所有日志实例
+}
+
+/**
+ * 设置日志实例的名称，用于路由规则按实例名称匹配
+ */
+func (logger *loggerBase) SetName(name string) {
+	logger.loggerName = name
+}
+
+/**
+ * 添加一条路由规则，规则按添加顺序匹配（应在开始输出日志之前完成添加）
+ * 未添加任何规则时日志分发到全部书写器；添加规则后，未匹配任何规则的日志不再输出，需要时可添加一条无条件的规则兜底
+ */
+func (logger *loggerBase) AddRoute(route Route) {
+	registered := registeredRoute{route: route}
+	if len(route.Levels) > 0 {
+		registered.levels = make(map[string]bool, len(route.Levels))
+		for _, level := range route.Levels {
+			registered.levels[strings.ToUpper(strings.TrimSpace(level))] = true
+		}
+	}
+	if len(route.Loggers) > 0 {
+		registered.loggers = make(map[string]bool, len(route.Loggers))
+		for _, name := range route.Loggers {
+			registered.loggers[strings.TrimSpace(name)] = true
+		}
+	}
+	logger.routes = append(logger.routes, registered)
+}
+
+/**
+ * 清除全部路由规则，恢复为分发到全部书写器
+ */
+func (logger *loggerBase) ClearRoutes() {
+	logger.routes = nil
+}
+
+/**
+ * 按路由规则获取日志要发送到的书写器名称（已去重，保持规则中的顺序）
+ */
+func (logger *loggerBase) getRoutedWriters(msg *LogMsg) []string {
+	var writerNames []string
+	for i := range logger.routes {
+		registered := &logger.routes[i]
+		if !registered.match(msg) {
+			continue
+		}
+		for _, name := range registered.route.Writers {
+			if !containsString(writerNames, name) {
+				writerNames = append(writerNames, name)
+			}
+		}
+		if !registered.route.Continue {
+			break
+		}
+	}
+	return writerNames
+}
+
+/**
+ * 判断日志是否满足规则的全部条件
+ */
+func (registered *registeredRoute) match(msg *LogMsg) bool {
+	if registered.levels != nil && !registered.levels[msg.msgLevel] {
+		return false
+	}
+	if registered.loggers != nil && !registered.loggers[msg.loggerName] {
+		return false
+	}
+	if registered.route.CallerPackage != "" && !strings.HasPrefix(getCallerPackage(msg.targetPoint), registered.route.CallerPackage) {
+		return false
+	}
+	for key, expected := range registered.route.Fields {
+		value, ok := msg.getFieldValue(key)
+		if !ok || expected != "*" && fmt.Sprint(value) != expected {
+			return false
+		}
+	}
+	return true
+}
+
+/**
+ * 获取指定字段的值
+ */
+func (msg *LogMsg) getFieldValue(key string) (interface{}, bool) {
+	for _, field := range msg.fields {
+		if field.Key == key {
+			return field.Value, true
+		}
+	}
+	return nil, false
+}
+
+/**
+ * 从日志记录点中解析调用函数所在的包路径，例如"main.go 12 github.com/foo/bar.(*Server).Run() [1]"解析为"github.com/foo/bar"
+ */
+func getCallerPackage(targetPoint string) string {
+	parts := strings.Fields(targetPoint)
+	if len(parts) < 3 {
+		return ""
+	}
+	function := parts[2]
+	pkgStart := strings.LastIndex(function, "/") + 1
+	if dotIndex := strings.Index(function[pkgStart:], "."); dotIndex >= 0 {
+		return function[:pkgStart+dotIndex]
+	}
+	return function
+}
+
+/**
+ * 判断字符串切片中是否包含指定字符串
+ */
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
+
+/**
+ * 根据Init配置生成路由规则：
+ * routes=errors,audit,app                规则名称，按顺序匹配
+ * route.<规则名>.levels=error,fatal       匹配的级别
+ * route.<规则名>.logger=payments          匹配的日志实例名称（多个以逗号分隔）
+ * route.<规则名>.caller=github.com/foo    匹配的调用点包路径前缀
+ * route.<规则名>.fields=category=audit    匹配的字段值（多个以逗号分隔）
+ * route.<规则名>.writers=error,console    发送到的书写器
+ * route.<规则名>.continue=true            匹配后是否继续匹配后续规则
+ */
+func newRoutesFromConfig(configs map[string]string) []Route {
+	var routes []Route
+	for _, name := range strings.Split(configs["routes"], ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		prefix := "route." + name + "."
+		route := Route{Name: name, CallerPackage: strings.TrimSpace(configs[prefix+"caller"])}
+		route.Levels = splitConfigList(configs[prefix+"levels"])
+		route.Loggers = splitConfigList(configs[prefix+"logger"])
+		route.Writers = splitConfigList(configs[prefix+"writers"])
+		route.Continue = strings.EqualFold(strings.TrimSpace(configs[prefix+"continue"]), "true")
+		for _, pair := range splitConfigList(configs[prefix+"fields"]) {
+			keyValue := strings.SplitN(pair, "=", 2)
+			if len(keyValue) != 2 {
+				printError("log route field config error: %s. expected key=value", pair)
+				continue
+			}
+			if route.Fields == nil {
+				route.Fields = make(map[string]string)
+			}
+			route.Fields[strings.TrimSpace(keyValue[0])] = strings.TrimSpace(keyValue[1])
+		}
+		if len(route.Writers) == 0 {
+			printError("log route %s has no writers.", name)
+		}
+		routes = append(routes, route)
+	}
+	return routes
+}
+
+/**
+ * 将逗号分隔的配置项拆分为列表，忽略空项
+ */
+func splitConfigList(config string) []string {
+	var values []string
+	for _, value := range strings.Split(config, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
@@ -0,0 +1,84 @@
+package loglet
+
+import (
+	"testing"
+)
+
+func TestRoutes(t *testing.T) {
+	logger := NewLogger()
+	logger.CloseWriters()
+	logger.SetName("payments")
+	errorWriter, auditWriter, appWriter := new(captureWriter), new(captureWriter), new(captureWriter)
+	logger.RegisterWriter("error", errorWriter)
+	logger.RegisterWriter("audit", auditWriter)
+	logger.RegisterWriter("app", appWriter)
+
+	routes := newRoutesFromConfig(map[string]string{
+		"routes":                "errors,audit,app",
+		"route.errors.levels":   "error,fatal",
+		"route.errors.writers":  "error",
+		"route.errors.continue": "true",
+		"route.audit.fields":    "category=audit",
+		"route.audit.writers":   "audit",
+		"route.app.writers":     "app",
+	})
+	if len(routes) != 3 || !routes[0].Continue || routes[1].Fields["category"] != "audit" {
+		t.Fatalf("unexpected routes from config: %+v", routes)
+	}
+	for _, route := range routes {
+		logger.AddRoute(route)
+	}
+	logger.AddHook(HookFunc(func(msg *LogMsg) bool {
+		if msg.Content() == "user login" {
+			msg.SetField("category", "audit")
+		}
+		return true
+	}))
+
+	logger.Info("request done")
+	logger.Info("user login")
+	logger.Error("db down")
+
+	if msgs := errorWriter.messages(); len(msgs) != 1 || msgs[0].Content() != "db down" {
+		t.Errorf("error writer should receive errors only: %v", msgs)
+	}
+	if msgs := auditWriter.messages(); len(msgs) != 1 || msgs[0].Content() != "user login" {
+		t.Errorf("audit writer should receive audit messages only: %v", msgs)
+	}
+	//ERROR规则设置了continue，因此错误日志同时进入兜底规则
+	if msgs := appWriter.messages(); len(msgs) != 2 || msgs[0].Content() != "request done" || msgs[1].Content() != "db down" {
+		t.Errorf("app writer should receive the rest: %v", msgs)
+	}
+	if appWriter.messages()[0].LoggerName() != "payments" {
+		t.Errorf("logger name should be set on messages")
+	}
+	logger.CloseWriters()
+}
+
+func TestRouteMatchers(t *testing.T) {
+	logger := NewLogger()
+	logger.CloseWriters()
+	writer, otherWriter := new(captureWriter), new(captureWriter)
+	logger.RegisterWriter("capture", writer)
+	logger.RegisterWriter("other", otherWriter)
+	logger.AddRoute(Route{Loggers: []string{"orders"}, Writers: []string{"other"}})
+	logger.AddRoute(Route{Writers: []string{"capture"}})
+
+	logger.Info("from test")
+	logger.SetName("orders")
+	logger.Info("from orders")
+	logger.SetName("")
+	logger.ClearRoutes()
+	logger.Info("broadcast")
+
+	if msgs := writer.messages(); len(msgs) != 2 || msgs[0].Content() != "from test" {
+		t.Errorf("catch-all route should match: %v", msgs)
+	}
+	if msgs := otherWriter.messages(); len(msgs) != 2 || msgs[0].Content() != "from orders" {
+		t.Errorf("logger name route should match: %v", msgs)
+	}
+	if pkg := getCallerPackage("server.go 12 github.com/foo/bar.(*Server).Run() [1]"); pkg != "github.com/foo/bar" {
+		t.Errorf("unexpected caller package: %s", pkg)
+	}
+	logger.CloseWriters()
+}
@@ -3,35 +3,162 @@ package loglet
 import (
 	"fmt"
 	"os"
+	"strings"
+	"sync/atomic"
 )
 
 /**
- * 日志书写器抽象定义
+ * 日志书写器抽象定义，WriteLog、Close返回的错误由日志记录器交给错误处理函数
  */
 type LogWriter interface {
-	WriteLog(msg *LogMsg)
-	Close()
+	WriteLog(msg *LogMsg) error
+	Close() error
 }
 
 /**
- * 控制台日志书写器定义
+ * 控制台输出的颜色模式
+ */
+const (
+	COLOR_AUTO   = "auto"   //根据是否输出到终端以及NO_COLOR、FORCE_COLOR环境变量自动判断
+	COLOR_ALWAYS = "always" //始终输出彩色
+	COLOR_NEVER  = "never"  //始终输出纯文本
+)
+
+/**
+ * 控制台输出使用的ANSI颜色控制符
+ */
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiDim    = "\x1b[2m"
+	ansiRed    = "\x1b[31m"
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+	ansiBlue   = "\x1b[34m"
+	ansiCyan   = "\x1b[36m"
+)
+
+/**
+ * 控制台日志书写器定义（零值输出纯文本）
  */
 type ConsoleWriter struct {
+	writerCounters      //写入字节数、错误等统计
+	stdoutColor    bool //输出到stdout时是否使用彩色
+	stderrColor    bool //输出到stderr时是否使用彩色
+}
+
+/**
+ * 创建一个控制台日志书写器，colorMode可选auto、always、never，为空时按auto处理
+ */
+func NewConsoleWriter(colorMode string) *ConsoleWriter {
+	logger := new(ConsoleWriter)
+	logger.SetColorMode(colorMode)
+	return logger
+}
+
+/**
+ * 设置控制台输出的颜色模式
+ */
+func (logger *ConsoleWriter) SetColorMode(colorMode string) {
+	switch strings.ToLower(strings.TrimSpace(colorMode)) {
+	case COLOR_ALWAYS, "true", "on":
+		logger.stdoutColor, logger.stderrColor = true, true
+	case COLOR_NEVER, "false", "off":
+		logger.stdoutColor, logger.stderrColor = false, false
+	case COLOR_AUTO, "":
+		logger.stdoutColor, logger.stderrColor = isColorTerminal(os.Stdout), isColorTerminal(os.Stderr)
+	default:
+		printError("unknown console color mode: %s. use default: auto", colorMode)
+		logger.stdoutColor, logger.stderrColor = isColorTerminal(os.Stdout), isColorTerminal(os.Stderr)
+	}
 }
 
 /**
  * 向控制台输出日志
  */
-func (logger *ConsoleWriter) WriteLog(msg *LogMsg) {
+func (logger *ConsoleWriter) WriteLog(msg *LogMsg) error {
+	var writeSize int
+	var err error
 	if msg.msgLevel == ERROR || msg.msgLevel == FATAL {
-		fmt.Fprintf(os.Stderr, msg.getFormattedMsg()+"\n")
+		writeSize, err = fmt.Fprintln(os.Stderr, logger.formatMsg(msg, logger.stderrColor))
 	} else {
-		fmt.Println(msg.getFormattedMsg())
+		writeSize, err = fmt.Fprintln(os.Stdout, logger.formatMsg(msg, logger.stdoutColor))
+	}
+	atomic.AddUint64(&logger.bytes, uint64(writeSize))
+	if err != nil {
+		atomic.AddUint64(&logger.errors, 1)
 	}
+	return err
+}
+
+/**
+ * 获取控制台日志书写器的统计信息
+ */
+func (logger *ConsoleWriter) WriterStats() WriterStats {
+	return logger.writerCounters.getStats()
+}
+
+/**
+ * 格式化控制台日志，彩色模式下时间暗显、级别按颜色区分、字段名高亮
+ */
+func (logger *ConsoleWriter) formatMsg(msg *LogMsg, colored bool) string {
+	if !colored {
+		return msg.getFormattedMsg()
+	}
+	var buf strings.Builder
+	buf.WriteString(ansiDim + msg.msgTime.Format(textTimeLayout) + " " + msg.targetPoint + ansiReset)
+	buf.WriteString(" " + getLevelColor(msg.msgLevel) + "[" + msg.msgLevel + "]" + ansiReset + " ")
+	buf.WriteString(msg.msgContent)
+	for _, field := range msg.fields {
+		buf.WriteString(" " + ansiCyan + field.Key + ansiReset + "=" + formatFieldValue(field.Value))
+	}
+	return buf.String()
 }
 
 /**
  * 关闭控制台日志书写器（控制台本身不需要关闭，为了实现多态，这里补足Close方法）
  */
-func (logger *ConsoleWriter) Close() {
+func (logger *ConsoleWriter) Close() error {
+	return nil
+}
+
+/**
+ * 获取日志级别对应的颜色
+ */
+func getLevelColor(level string) string {
+	switch level {
+	case DEBUG:
+		return ansiBlue
+	case INFO:
+		return ansiGreen
+	case WARN:
+		return ansiYellow
+	case ERROR:
+		return ansiRed
+	case FATAL:
+		return ansiBold + ansiRed
+	default:
+		return ""
+	}
+}
+
+/**
+ * 判断是否应向指定文件输出彩色日志：NO_COLOR优先禁用，FORCE_COLOR强制启用，否则只在终端中启用
+ */
+func isColorTerminal(file *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	forceColor, forced := os.LookupEnv("FORCE_COLOR")
+	if forced {
+		return forceColor != "0" && strings.ToLower(forceColor) != "false"
+	}
+	if os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	return fileInfo.Mode()&os.ModeCharDevice != 0
 }
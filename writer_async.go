@@ -0,0 +1,199 @@
+package loglet
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+/**
+ * 异步书写器队列满时的处理策略
+ */
+const (
+	OVERFLOW_BLOCK       = "block"       //阻塞调用方，直到队列有空位（不丢日志）
+	OVERFLOW_DROP_NEWEST = "drop_newest" //丢弃当前要写入的日志
+	OVERFLOW_DROP_OLDEST = "drop_oldest" //丢弃队列中最早的日志，为当前日志腾出空位
+)
+
+/**
+ * 异步书写器的可选配置
+ */
+type AsyncWriterOptions struct {
+	QueueSize      int    //队列容量，默认10000
+	OverflowPolicy string //队列满时的处理策略，默认OVERFLOW_BLOCK
+}
+
+/**
+ * 异步书写器，为任意书写器增加有界队列及后台写入协程，避免慢速书写器（文件、网络等）阻塞调用方
+ */
+type AsyncWriter struct {
+	writerCounters //丢弃等统计，字节数、错误等由内部书写器统计
+	writerErrors   //后台协程中内部书写器的写入错误通过错误处理函数报告
+	inner          LogWriter
+	overflowPolicy string
+	queue          chan *LogMsg
+	closeLock      sync.RWMutex //写入时持有读锁，关闭队列时持有写锁，避免向已关闭的队列写入
+	closed         bool
+	pendingLock    sync.Mutex
+	pendingCond    *sync.Cond //等待中的日志全部写完时通知Flush
+	pending        int        //已入队但尚未写完的日志条数
+	done           chan struct{}
+}
+
+/**
+ * 创建一个包装指定书写器的异步书写器，opts可以为nil
+ */
+func NewAsyncWriter(inner LogWriter, opts *AsyncWriterOptions) *AsyncWriter {
+	queueSize, overflowPolicy := 10000, OVERFLOW_BLOCK
+	if opts != nil {
+		if opts.QueueSize > 0 {
+			queueSize = opts.QueueSize
+		}
+		switch opts.OverflowPolicy {
+		case OVERFLOW_BLOCK, OVERFLOW_DROP_NEWEST, OVERFLOW_DROP_OLDEST:
+			overflowPolicy = opts.OverflowPolicy
+		case "":
+		default:
+			printError("unknown async writer overflow policy: %s. use default: %s", opts.OverflowPolicy, OVERFLOW_BLOCK)
+		}
+	}
+	logger := &AsyncWriter{inner: inner, overflowPolicy: overflowPolicy, queue: make(chan *LogMsg, queueSize), done: make(chan struct{})}
+	logger.pendingCond = sync.NewCond(&logger.pendingLock)
+	go logger.persistLog()
+	return logger
+}
+
+/**
+ * 设置错误处理函数，内部书写器可以自行报告错误时一并设置
+ */
+func (logger *AsyncWriter) SetErrorHandler(handler ErrorHandler) {
+	logger.writerErrors.SetErrorHandler(handler)
+	if reporter, ok := logger.inner.(ErrorReporter); ok {
+		reporter.SetErrorHandler(handler)
+	}
+}
+
+/**
+ * 将日志放入队列，由后台协程写入内部书写器；日志被丢弃时返回ErrQueueFull或ErrWriterClosed（只计数，不报告）
+ */
+func (logger *AsyncWriter) WriteLog(msg *LogMsg) error {
+	logger.closeLock.RLock()
+	defer logger.closeLock.RUnlock()
+	if logger.closed {
+		atomic.AddUint64(&logger.drops, 1)
+		return ErrWriterClosed
+	}
+	logger.addPending(1)
+	switch logger.overflowPolicy {
+	case OVERFLOW_DROP_NEWEST:
+		select {
+		case logger.queue <- msg:
+		default:
+			atomic.AddUint64(&logger.drops, 1)
+			logger.addPending(-1)
+			return ErrQueueFull
+		}
+	case OVERFLOW_DROP_OLDEST:
+		for {
+			select {
+			case logger.queue <- msg:
+				return nil
+			default:
+			}
+			//队列已满时取出最早的一条丢弃后重试（后台协程可能同时取走，因此不阻塞）
+			select {
+			case <-logger.queue:
+				atomic.AddUint64(&logger.drops, 1)
+				logger.addPending(-1)
+			default:
+			}
+		}
+	default:
+		logger.queue <- msg
+	}
+	return nil
+}
+
+/**
+ * 后台协程，将队列中的日志依次写入内部书写器，队列关闭并清空后退出
+ */
+func (logger *AsyncWriter) persistLog() {
+	for msg := range logger.queue {
+		logger.writeInner(msg)
+		logger.addPending(-1)
+	}
+	close(logger.done)
+}
+
+/**
+ * 向内部书写器写入一条日志，避免内部书写器的panic导致后台协程退出；
+ * 写入错误在后台协程中无法返回给调用方，内部书写器不能自行报告时由异步书写器报告
+ */
+func (logger *AsyncWriter) writeInner(msg *LogMsg) {
+	defer func() {
+		err := recover()
+		if err != nil {
+			logger.reportError("write", fmt.Errorf("panic: %v", err))
+		}
+	}()
+	err := logger.inner.WriteLog(msg)
+	if _, ok := logger.inner.(ErrorReporter); !ok && err != nil {
+		logger.reportError("write", err)
+	}
+}
+
+/**
+ * 调整等待写入的日志条数，归零时唤醒Flush
+ */
+func (logger *AsyncWriter) addPending(delta int) {
+	logger.pendingLock.Lock()
+	logger.pending += delta
+	if logger.pending == 0 {
+		logger.pendingCond.Broadcast()
+	}
+	logger.pendingLock.Unlock()
+}
+
+/**
+ * 阻塞直到已入队的日志全部写入内部书写器
+ */
+func (logger *AsyncWriter) Flush() {
+	logger.pendingLock.Lock()
+	for logger.pending > 0 {
+		logger.pendingCond.Wait()
+	}
+	logger.pendingLock.Unlock()
+}
+
+/**
+ * 获取异步书写器的统计信息（合并内部书写器的统计）
+ */
+func (logger *AsyncWriter) WriterStats() WriterStats {
+	var stats WriterStats
+	if statsWriter, ok := logger.inner.(StatsWriter); ok {
+		stats = statsWriter.WriterStats()
+	}
+	stats.Drops += atomic.LoadUint64(&logger.drops)
+	stats.QueueDepth, stats.QueueCapacity = len(logger.queue), cap(logger.queue)
+	return stats
+}
+
+/**
+ * 关闭异步书写器：停止接收新日志，等待队列中的日志全部写完后关闭内部书写器，返回内部书写器的关闭错误
+ */
+func (logger *AsyncWriter) Close() error {
+	logger.closeLock.Lock()
+	if logger.closed {
+		logger.closeLock.Unlock()
+		return nil
+	}
+	logger.closed = true
+	close(logger.queue)
+	logger.closeLock.Unlock()
+	<-logger.done
+	err := logger.inner.Close()
+	if _, ok := logger.inner.(ErrorReporter); !ok && err != nil {
+		logger.reportError("close", err)
+	}
+	return err
+}
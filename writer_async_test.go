@@ -0,0 +1,77 @@
+package loglet
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+/**
+ * 写入前等待放行的书写器，用于模拟慢速书写器
+ */
+type gatedWriter struct {
+	captureWriter
+	gate   chan struct{}
+	closed bool
+}
+
+func (logger *gatedWriter) WriteLog(msg *LogMsg) error {
+	<-logger.gate
+	return logger.captureWriter.WriteLog(msg)
+}
+
+func (logger *gatedWriter) Close() error {
+	logger.closed = true
+	return nil
+}
+
+func TestAsyncWriterOverflow(t *testing.T) {
+	for policy, expected := range map[string][]string{
+		OVERFLOW_DROP_NEWEST: {"msg-0", "msg-1", "msg-2"},
+		OVERFLOW_DROP_OLDEST: {"msg-0", "msg-3", "msg-4"},
+	} {
+		inner := &gatedWriter{gate: make(chan struct{})}
+		writer := NewAsyncWriter(inner, &AsyncWriterOptions{QueueSize: 2, OverflowPolicy: policy})
+		//第一条日志被后台协程取出后阻塞在书写器中，其余日志留在队列里
+		writer.WriteLog(&LogMsg{msgLevel: INFO, msgContent: "msg-0"})
+		for writer.WriterStats().QueueDepth != 0 {
+			time.Sleep(time.Millisecond)
+		}
+		for i := 1; i < 5; i++ {
+			writer.WriteLog(&LogMsg{msgLevel: INFO, msgContent: "msg-" + strconv.Itoa(i)})
+		}
+		if stats := writer.WriterStats(); stats.Drops != 2 || stats.QueueDepth != 2 || stats.QueueCapacity != 2 {
+			t.Errorf("%s: unexpected stats: %+v", policy, stats)
+		}
+		close(inner.gate)
+		writer.Flush()
+		msgs := inner.messages()
+		if len(msgs) != len(expected) {
+			t.Fatalf("%s: expected %d messages, got %d", policy, len(expected), len(msgs))
+		}
+		for i, msg := range msgs {
+			if msg.Content() != expected[i] {
+				t.Errorf("%s: expected %s, got %s", policy, expected[i], msg.Content())
+			}
+		}
+		writer.Close()
+	}
+}
+
+func TestAsyncWriterClose(t *testing.T) {
+	inner := &gatedWriter{gate: make(chan struct{})}
+	close(inner.gate)
+	writer := NewAsyncWriter(inner, nil)
+	for i := 0; i < 100; i++ {
+		writer.WriteLog(&LogMsg{msgLevel: INFO, msgContent: "msg-" + strconv.Itoa(i)})
+	}
+	writer.Close()
+	if len(inner.messages()) != 100 || !inner.closed {
+		t.Errorf("close should drain the queue and close the inner writer: %d", len(inner.messages()))
+	}
+	writer.WriteLog(&LogMsg{msgLevel: INFO, msgContent: "after close"})
+	writer.Close()
+	if stats := writer.WriterStats(); stats.Drops != 1 || len(inner.messages()) != 100 {
+		t.Errorf("messages after close should be dropped: %+v", stats)
+	}
+}
@@ -0,0 +1,235 @@
+package loglet
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+/**
+ * 故障转移书写器默认的探测周期，切换到后备书写器后每隔该周期尝试一次优先级更高的书写器
+ */
+const defaultFailoverProbeInterval = 30 * time.Second
+
+/**
+ * 故障转移书写器：日志写入当前书写器，当前书写器返回错误时依次切换到下一个书写器；
+ * 处于后备书写器时定期用一条日志探测优先级更高的书写器，写入成功即切回（例如网络优先、本地文件兜底）；
+ * 子书写器需要同步返回写入错误，需要异步写入时用NewAsyncWriter包装整个故障转移书写器
+ */
+type FailoverWriter struct {
+	writerCounters //各书写器的写入错误及全部失败时的丢弃统计
+	writerErrors   //切换到后备书写器及全部失败时通过错误处理函数报告
+	writers        []LogWriter
+	current        int           //当前使用的书写器下标，0为主书写器
+	probeInterval  time.Duration //处于后备书写器时探测优先级更高的书写器的周期
+	lastProbeTime  time.Time     //切换或上次探测的时间
+	writeLock      sync.Mutex
+}
+
+/**
+ * 创建一个故障转移书写器，primary为主书写器，secondaries按优先级从高到低排列
+ */
+func Failover(primary LogWriter, secondaries ...LogWriter) *FailoverWriter {
+	writers := append([]LogWriter{primary}, secondaries...)
+	return &FailoverWriter{writers: writers, probeInterval: defaultFailoverProbeInterval}
+}
+
+/**
+ * 设置探测优先级更高的书写器的周期（默认30秒）
+ */
+func (logger *FailoverWriter) SetProbeInterval(interval time.Duration) {
+	logger.writeLock.Lock()
+	defer logger.writeLock.Unlock()
+	logger.probeInterval = interval
+}
+
+/**
+ * 设置错误处理函数，能自行报告错误的子书写器一并设置
+ */
+func (logger *FailoverWriter) SetErrorHandler(handler ErrorHandler) {
+	logger.writeLock.Lock()
+	defer logger.writeLock.Unlock()
+	logger.writerErrors.SetErrorHandler(handler)
+	for _, writer := range logger.writers {
+		if reporter, ok := writer.(ErrorReporter); ok {
+			reporter.SetErrorHandler(handler)
+		}
+	}
+}
+
+/**
+ * 写入一条日志，全部书写器都写入失败时返回最后一个错误
+ */
+func (logger *FailoverWriter) WriteLog(msg *LogMsg) error {
+	logger.writeLock.Lock()
+	defer logger.writeLock.Unlock()
+	//到达探测周期时从主书写器开始尝试，否则从当前书写器开始
+	start := logger.current
+	if start > 0 && time.Since(logger.lastProbeTime) >= logger.probeInterval {
+		start = 0
+		logger.lastProbeTime = time.Now()
+	}
+	var lastErr error
+	for index := start; index < len(logger.writers); index++ {
+		err := logger.writers[index].WriteLog(msg)
+		if err != nil {
+			atomic.AddUint64(&logger.errors, 1)
+			lastErr = err
+			continue
+		}
+		if index != logger.current {
+			logger.switchTo(index, lastErr)
+		}
+		return nil
+	}
+	atomic.AddUint64(&logger.drops, 1)
+	logger.reportError("write", fmt.Errorf("all %d writers failed: %w", len(logger.writers), lastErr))
+	return lastErr
+}
+
+/**
+ * 切换当前书写器（调用方需持有写入锁），cause为导致切换到后备书写器的错误；
+ * 切换到后备书写器时作为当前书写器的写入错误报告，切回不是错误，不报告，可以通过Current获取状态
+ */
+func (logger *FailoverWriter) switchTo(index int, cause error) {
+	previous := logger.current
+	logger.current, logger.lastProbeTime = index, time.Now()
+	if index > previous {
+		logger.reportError("write", fmt.Errorf("writer %d failed, switched to writer %d: %w", previous, index, cause))
+	}
+}
+
+/**
+ * 获取当前使用的书写器下标，0为主书写器
+ */
+func (logger *FailoverWriter) Current() int {
+	logger.writeLock.Lock()
+	defer logger.writeLock.Unlock()
+	return logger.current
+}
+
+/**
+ * 获取故障转移书写器的统计信息，使用后备书写器期间视为降级状态
+ */
+func (logger *FailoverWriter) WriterStats() WriterStats {
+	stats := logger.writerCounters.getStats()
+	stats.Degraded = logger.Current() > 0
+	return stats
+}
+
+/**
+ * 关闭全部书写器，返回第一个关闭错误
+ */
+func (logger *FailoverWriter) Close() error {
+	return logger.closeWriters(logger.writers)
+}
+
+/**
+ * 分发书写器：日志达到输出级别后写入全部子书写器，可以作为一个整体注册或参与路由、故障转移
+ */
+type TeeWriter struct {
+	writerErrors //子书写器的写入错误通过错误处理函数报告
+	writers      []LogWriter
+	levelNum     int //输出级别，默认DEBUG即不过滤
+}
+
+/**
+ * 创建一个分发书写器
+ */
+func Tee(writers ...LogWriter) *TeeWriter {
+	return &TeeWriter{writers: writers}
+}
+
+/**
+ * 设置分发书写器的输出级别，低于该级别的日志不写入任何子书写器
+ */
+func (logger *TeeWriter) SetLevel(level string) {
+	logger.levelNum = getLevelNum(level)
+	if logger.levelNum < 0 {
+		printError("unknown log level for tee writer: %s. use default: DEBUG", level)
+		logger.levelNum = DEBUG_LEVEL
+	}
+}
+
+/**
+ * 设置错误处理函数，能自行报告错误的子书写器一并设置
+ */
+func (logger *TeeWriter) SetErrorHandler(handler ErrorHandler) {
+	logger.writerErrors.SetErrorHandler(handler)
+	for _, writer := range logger.writers {
+		if reporter, ok := writer.(ErrorReporter); ok {
+			reporter.SetErrorHandler(handler)
+		}
+	}
+}
+
+/**
+ * 将日志写入全部子书写器，某个子书写器失败不影响其他子书写器，返回第一个写入错误
+ */
+func (logger *TeeWriter) WriteLog(msg *LogMsg) error {
+	if getLevelNum(msg.msgLevel) < logger.levelNum {
+		return nil
+	}
+	var firstErr error
+	for _, writer := range logger.writers {
+		err := writer.WriteLog(msg)
+		if err == nil {
+			continue
+		}
+		if _, ok := writer.(ErrorReporter); !ok {
+			logger.reportError("write", err)
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+/**
+ * 获取分发书写器的统计信息（合并子书写器的统计）
+ */
+func (logger *TeeWriter) WriterStats() WriterStats {
+	var stats WriterStats
+	for _, writer := range logger.writers {
+		if statsWriter, ok := writer.(StatsWriter); ok {
+			writerStats := statsWriter.WriterStats()
+			stats.Messages += writerStats.Messages
+			stats.Bytes += writerStats.Bytes
+			stats.Drops += writerStats.Drops
+			stats.Errors += writerStats.Errors
+			stats.Rotations += writerStats.Rotations
+			stats.Deletions += writerStats.Deletions
+			stats.Degraded = stats.Degraded || writerStats.Degraded
+		}
+	}
+	return stats
+}
+
+/**
+ * 关闭全部子书写器，返回第一个关闭错误
+ */
+func (logger *TeeWriter) Close() error {
+	return logger.closeWriters(logger.writers)
+}
+
+/**
+ * 依次关闭子书写器，报告不能自行报告错误的子书写器的关闭错误，返回第一个关闭错误
+ */
+func (reporter *writerErrors) closeWriters(writers []LogWriter) error {
+	var firstErr error
+	for _, writer := range writers {
+		err := writer.Close()
+		if err == nil {
+			continue
+		}
+		if _, ok := writer.(ErrorReporter); !ok {
+			reporter.reportError("close", err)
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,85 @@
+package loglet
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFailoverWriter(t *testing.T) {
+	primary, secondary := new(captureWriter), new(captureWriter)
+	var reported []*WriterError
+	writer := Failover(primary, secondary)
+	writer.SetErrorHandler(func(err *WriterError) {
+		reported = append(reported, err)
+	})
+	writer.SetProbeInterval(time.Hour)
+
+	//主书写器失败后切换到后备书写器，之后的日志不再尝试主书写器
+	errDown := errors.New("connection refused")
+	primary.err = errDown
+	for _, content := range []string{"msg-0", "msg-1"} {
+		if err := writer.WriteLog(&LogMsg{msgLevel: INFO, msgContent: content}); err != nil {
+			t.Fatalf("failover should hide the primary error: %s", err)
+		}
+	}
+	if writer.Current() != 1 || len(primary.messages()) != 1 || len(secondary.messages()) != 2 {
+		t.Fatalf("writer should switch to the secondary: %d, %d, %d", writer.Current(), len(primary.messages()), len(secondary.messages()))
+	}
+	if len(reported) != 1 || reported[0].Op != "write" || !errors.Is(reported[0], errDown) || !writer.WriterStats().Degraded {
+		t.Errorf("switching should be reported once: %v", reported)
+	}
+
+	//到达探测周期时探测主书写器，写入成功即切回
+	primary.err = nil
+	writer.SetProbeInterval(0)
+	writer.WriteLog(&LogMsg{msgLevel: INFO, msgContent: "msg-2"})
+	if writer.Current() != 0 || len(primary.messages()) != 2 || len(secondary.messages()) != 2 {
+		t.Fatalf("writer should switch back to the primary: %d", writer.Current())
+	}
+	if len(reported) != 1 || writer.WriterStats().Degraded {
+		t.Errorf("switching back is not an error: %v", reported)
+	}
+
+	//全部书写器失败时返回错误并计入丢弃
+	primary.err, secondary.err = errDown, errDown
+	if err := writer.WriteLog(&LogMsg{msgLevel: INFO, msgContent: "msg-3"}); err != errDown || writer.WriterStats().Drops != 1 {
+		t.Errorf("all writers failed should return the error: %v", err)
+	}
+}
+
+func TestTeeWriter(t *testing.T) {
+	audit, debug := new(captureWriter), new(captureWriter)
+	tee := Tee(audit, debug)
+	tee.SetLevel("warn")
+	logger := NewLogger()
+	logger.CloseWriters()
+	logger.RegisterWriter("tee", tee)
+	logger.RegisterWriter("all", Tee(new(captureWriter)))
+	var reported []*WriterError
+	logger.SetErrorHandler(func(err *WriterError) {
+		reported = append(reported, err)
+	})
+
+	logger.Info("ignored")
+	errFull := errors.New("disk full")
+	audit.err = errFull
+	logger.Warn("warning")
+	if len(audit.messages()) != 1 || len(debug.messages()) != 1 {
+		t.Fatalf("tee should filter by its own level and write to all writers: %d, %d", len(audit.messages()), len(debug.messages()))
+	}
+	if len(reported) != 1 || reported[0].Writer != "tee" || reported[0].Op != "write" || !errors.Is(reported[0], errFull) {
+		t.Errorf("tee should report the failed writer once: %v", reported)
+	}
+
+	//组合使用：网络优先、本地文件兜底，同时输出到内存缓冲
+	network, local := new(captureWriter), new(captureWriter)
+	ring := NewRingWriter(nil)
+	network.err = errors.New("timeout")
+	combined := Tee(Failover(network, local), ring)
+	combined.WriteLog(&LogMsg{msgLevel: ERROR, msgContent: "combined"})
+	if len(local.messages()) != 1 || len(ring.Snapshot()) != 1 {
+		t.Errorf("composite writers should be nestable")
+	}
+	logger.CloseWriters()
+}
@@ -0,0 +1,92 @@
+package loglet
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+/**
+ * 书写器已关闭时WriteLog返回的错误
+ */
+var ErrWriterClosed = errors.New("log writer is closed")
+
+/**
+ * 异步书写器队列已满、日志被丢弃时返回的错误
+ */
+var ErrQueueFull = errors.New("log writer queue is full")
+
+/**
+ * 文件书写器处于降级状态、日志未写入文件时返回的错误（日志可能已交给备用书写器）
+ */
+var ErrWriterDegraded = errors.New("log writer is degraded")
+
+/**
+ * 书写器内部错误，包含出错的书写器名称、操作及原始错误
+ */
+type WriterError struct {
+	Writer string //注册书写器时使用的名称，书写器未注册到日志记录器时为空
+	Op     string //出错的操作：write、close、open、rotate、rename、retention、lock、link等
+	Err    error
+}
+
+/**
+ * 错误描述
+ */
+func (err *WriterError) Error() string {
+	if err.Writer == "" {
+		return "log writer " + err.Op + " error: " + err.Err.Error()
+	}
+	return "log writer " + err.Writer + " " + err.Op + " error: " + err.Err.Error()
+}
+
+/**
+ * 获取原始错误，便于errors.Is、errors.As判断
+ */
+func (err *WriterError) Unwrap() error {
+	return err.Err
+}
+
+/**
+ * 书写器内部错误的处理函数，可能在书写器的后台协程中调用，处理函数需要自行保证并发安全
+ */
+type ErrorHandler func(err *WriterError)
+
+/**
+ * 可以自行报告内部错误的书写器（例如后台协程中的滚动、清理错误），注册到日志记录器时会被注入错误处理函数；
+ * 这类书写器的WriteLog、Close返回的错误已经自行报告过，日志记录器不再重复报告
+ */
+type ErrorReporter interface {
+	SetErrorHandler(handler ErrorHandler)
+}
+
+/**
+ * 默认的错误处理函数，与原有行为一致，将错误打印到标准错误输出
+ */
+func defaultErrorHandler(err *WriterError) {
+	printError("%s.", err.Error())
+}
+
+/**
+ * 书写器通用的错误报告，未设置错误处理函数时使用默认处理函数
+ */
+type writerErrors struct {
+	errorHandler atomic.Value //ErrorHandler，后台协程中也会读取，因此原子读写
+}
+
+/**
+ * 设置错误处理函数，传入nil时恢复为默认处理函数
+ */
+func (reporter *writerErrors) SetErrorHandler(handler ErrorHandler) {
+	reporter.errorHandler.Store(handler)
+}
+
+/**
+ * 报告一个内部错误
+ */
+func (reporter *writerErrors) reportError(op string, err error) {
+	handler, _ := reporter.errorHandler.Load().(ErrorHandler)
+	if handler == nil {
+		handler = defaultErrorHandler
+	}
+	handler(&WriterError{Op: op, Err: err})
+}
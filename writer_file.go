@@ -1,12 +1,12 @@
 package loglet
 
 import (
-	"io/ioutil"
+	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
-	"sort"
-	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -14,18 +14,46 @@ import (
  * 文件日志书写器定义
  */
 type FileWriter struct {
+	writerCounters    //写入字节数、错误、滚动、清理等统计
+	writerErrors      //滚动、清理、降级等内部错误通过错误处理函数报告
 	fileName          string
 	rotateDaily       bool
 	rotateSize        int64
 	logFile           *os.File
-	fileRollerCounter int //日志文件滚动计数器
-	logFileReserveNum int //保留历史文件的个数
-	bufferChan        chan *LogMsg
+	fileRollerCounter int             //日志文件滚动计数器
+	namingScheme      NamingScheme    //滚动文件的命名方式，为nil时使用时间戳命名
+	currentLink       bool            //软链接模式：直接写入分段文件，基名为指向当前分段的软链接
+	segmentPath       string          //软链接模式下当前写入的分段文件路径
+	multiProcess      bool            //多进程模式：多个进程写入同一文件时通过文件锁协调写入及滚动
+	processLock       *os.File        //多进程模式下用于加锁的文件（base.ext.lock）
+	reopenInterval    time.Duration   //检查文件是否被外部移走或截断的周期
+	lastCheckTime     time.Time       //上次检查文件的时间
+	observedSize      int64           //已知的文件大小下限，文件变小说明被截断
+	openedInfo        os.FileInfo     //当前打开文件的信息，用于判断日志路径是否仍指向该文件
+	openedGeneration  uint64          //打开当前文件时的重新打开通知次数
+	degraded          uint32          //文件无法写入时进入降级状态（atomic读写，1为降级）
+	retryInterval     time.Duration   //降级状态下重试写入文件的间隔
+	nextRetryTime     time.Time       //降级状态下下次重试写入文件的时间
+	fallback          LogWriter       //降级状态下的备用书写器
+	lastErrorReport   time.Time       //上次报告写入错误的时间
+	suppressedErrors  int             //自上次报告以来未报告的错误数
+	retention         RetentionPolicy //历史文件的保留策略
+	retentionInterval time.Duration   //保留策略的检查周期
+	retentionStop     chan struct{}   //停止定时检查保留策略，未启动时为nil
+	writeLock         sync.Mutex      //写入、滚动及关闭需要互斥
+	asyncWriter       *AsyncWriter    //调用Init后通过后台协程写入文件，为nil时在调用方的协程中同步写入
 }
 
+/**
+ * 初始化文件日志书写器：与原有行为一致，启动后台协程，日志先放入缓存队列再写入文件，避免文件读写慢阻塞调用方；
+ * 不调用Init时为同步写入，可以自行用NewAsyncWriter包装以配置队列容量及溢出策略
+ */
 func (logger *FileWriter) Init() {
-	logger.bufferChan = make(chan *LogMsg, 10000)
-	go logger.persistLog()
+	logger.writeLock.Lock()
+	defer logger.writeLock.Unlock()
+	if logger.asyncWriter == nil {
+		logger.asyncWriter = NewAsyncWriter(syncFileWriter{file: logger}, nil)
+	}
 }
 
 /**
@@ -36,14 +64,43 @@ func (logger *FileWriter) SetFileBaseName(fileName string) {
 }
 
 /**
- * 设置日志文件保留的个数
+ * 设置历史日志文件保留的个数（等同于设置保留策略中的MaxFiles），个数不含当前写入的文件；
+ * 旧版本的个数包含当前文件，因此相同的设置会比旧版本多保留一个历史文件
  */
 func (logger *FileWriter) SetFileReserveNum(num int) {
+	logger.writeLock.Lock()
+	defer logger.writeLock.Unlock()
 	if num > 0 && num < 1000 {
-		logger.logFileReserveNum = num
+		logger.retention.MaxFiles = num
 	} else {
-		logger.logFileReserveNum = 10
+		logger.retention.MaxFiles = 10
+	}
+}
+
+/**
+ * 设置滚动文件的命名方式，已启用软链接模式时命名方式需要支持生成分段文件名（例如NumberedNaming不支持）
+ */
+func (logger *FileWriter) SetNamingScheme(scheme NamingScheme) error {
+	logger.writeLock.Lock()
+	defer logger.writeLock.Unlock()
+	if scheme == nil {
+		scheme = TimestampNaming{}
+	}
+	if _, ok := scheme.(segmentNamer); logger.currentLink && !ok {
+		return errLinkNotSupported
+	}
+	logger.namingScheme = scheme
+	return nil
+}
+
+/**
+ * 获取滚动文件的命名方式
+ */
+func (logger *FileWriter) getNamingScheme() NamingScheme {
+	if logger.namingScheme == nil {
+		return TimestampNaming{}
 	}
+	return logger.namingScheme
 }
 
 /**
@@ -54,61 +111,111 @@ func (logger *FileWriter) SetRotateSize(size int64) {
 }
 
 /**
- * 接收分发过来的日志
+ * 设置错误处理函数（注册到日志记录器时自动设置）
  */
-func (logger *FileWriter) WriteLog(msg *LogMsg) {
-	//为了避免日志文件读写慢阻塞主进程，先通过管道缓存
-	logger.bufferChan <- msg
+func (logger *FileWriter) SetErrorHandler(handler ErrorHandler) {
+	logger.writeLock.Lock()
+	defer logger.writeLock.Unlock()
+	logger.writerErrors.SetErrorHandler(handler)
+	if logger.asyncWriter != nil {
+		logger.asyncWriter.SetErrorHandler(handler)
+	}
 }
 
 /**
- * 将缓存的日志写入文件
+ * 接收分发过来的日志：调用过Init时放入缓存队列，否则在调用方的协程中同步写入文件，日志未写入文件时返回错误（错误已经报告过）
  */
-func (logger *FileWriter) persistLog() {
-	for {
-		msg := <-logger.bufferChan
-		logger.writeLogToFile(msg)
+func (logger *FileWriter) WriteLog(msg *LogMsg) error {
+	if asyncWriter := logger.asyncWriter; asyncWriter != nil {
+		return asyncWriter.WriteLog(msg)
 	}
+	return logger.writeLogSync(msg)
+}
+
+/**
+ * 在当前协程中将日志写入文件
+ */
+func (logger *FileWriter) writeLogSync(msg *LogMsg) error {
+	logger.writeLock.Lock()
+	defer logger.writeLock.Unlock()
+	return logger.writeLogToFile(msg)
 }
 
 /**
  * 向日志文件中输出日志
  */
-func (logger *FileWriter) writeLogToFile(msg *LogMsg) {
+func (logger *FileWriter) writeLogToFile(msg *LogMsg) (err error) {
 	defer func() {
-		err := recover()
-		if err != nil {
-			printError("log internal err: %v", err)
+		panicErr := recover()
+		if panicErr != nil {
+			err = fmt.Errorf("panic: %v", panicErr)
+			logger.reportError("write", err)
 		}
 	}()
-	logger.fileRollerCounter++
-	//为了避免频繁判断日志文件大小，导致性能下降，每写入1K条日志才判断是否要滚日志文件
-	if logger.fileRollerCounter > 1000 {
-		logger.rollLogFile()
-		logger.deleteExpiredLogFile()
-		logger.fileRollerCounter = 0
+	//降级状态下未到重试时间时不访问文件
+	if logger.skipDegradedWrite(msg) {
+		return ErrWriterDegraded
+	}
+	//收到重新打开的通知（例如reopen_on_signal配置的信号）后重新打开文件
+	logger.reopenIfRequested()
+	if logger.multiProcess {
+		//多进程模式下写入及滚动均在进程间文件锁内完成，每次写入前确认文件是否已被其他进程滚动
+		if err = logger.lockProcess(); err != nil {
+			atomic.AddUint64(&logger.errors, 1)
+			logger.reportError("lock", err)
+			return err
+		}
+		defer logger.unlockProcess()
+		logger.reopenIfChanged()
+		//检查文件时已经取得了当前文件的大小，不再为判断滚动单独获取文件信息
+		if logger.logFile != nil && logger.observedSize >= logger.getRotateSize() {
+			logger.rotateLogFile()
+		}
+	} else {
+		//定时检查文件是否被外部（例如logrotate）移走、截断或删除
+		if time.Since(logger.lastCheckTime) >= logger.getReopenCheckInterval() {
+			logger.reopenIfChanged()
+		}
+		logger.fileRollerCounter++
+		//为了避免频繁判断日志文件大小，导致性能下降，每写入1K条日志才判断是否要滚日志文件
+		if logger.fileRollerCounter > 1000 {
+			logger.rollLogFile()
+			logger.fileRollerCounter = 0
+		}
 	}
 	logFile, err := logger.getLoggingFile()
 	if err != nil {
-		printError("can not init log file: %s. error: %s.", logger.fileName, err.Error())
-		return
+		logger.handleWriteError(msg, "open", err)
+		return err
 	}
-	_, err = logFile.WriteString(msg.getFormattedMsg())
+	logger.startRetentionTicker()
+	//根据系统不同输入换行符，日志内容与换行符通过一次写入完成，避免多个进程追加写入时相互交错
+	lineEnd := "\n"
+	if runtime.GOOS == "windows" {
+		lineEnd = "\r\n"
+	}
+	writeSize, err := logFile.WriteString(msg.getFormattedMsg() + lineEnd)
+	atomic.AddUint64(&logger.bytes, uint64(writeSize))
+	logger.observedSize += int64(writeSize)
 	if err != nil {
-		printError("can not write log to file: %s. error: %s.", logger.fileName, err.Error())
-		return
+		logger.handleWriteError(msg, "write", err)
+		return err
 	}
-	//根据系统不同输入换行符
-	switch runtime.GOOS {
-	case "linux":
-		logFile.WriteString("\n")
-	case "windows":
-		logFile.WriteString("\r\n")
-	case "darwin":
-		logFile.WriteString("\n")
-	default:
-		logFile.WriteString("\n")
+	logger.recoverFromDegraded()
+	return nil
+}
+
+/**
+ * 获取文件日志书写器的统计信息
+ */
+func (logger *FileWriter) WriterStats() WriterStats {
+	stats := logger.writerCounters.getStats()
+	stats.Degraded = logger.IsDegraded()
+	if asyncWriter := logger.asyncWriter; asyncWriter != nil {
+		stats.Drops += atomic.LoadUint64(&asyncWriter.drops)
+		stats.QueueDepth, stats.QueueCapacity = len(asyncWriter.queue), cap(asyncWriter.queue)
 	}
+	return stats
 }
 
 /**
@@ -140,11 +247,27 @@ func (logger *FileWriter) getLoggingFile() (*os.File, error) {
 			return nil, err
 		}
 	}
+	filePath := logger.fileName
 	var err error
-	logger.logFile, err = os.OpenFile(logger.fileName, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if logger.currentLink {
+		if filePath, err = logger.getSegmentPath(); err != nil {
+			return nil, err
+		}
+	}
+	logger.logFile, err = os.OpenFile(filePath, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
 	if err != nil {
 		return nil, err
 	}
+	if logger.currentLink {
+		if err = repointLink(logger.fileName, filePath); err != nil {
+			logger.reportError("link", err)
+		}
+	}
+	logger.observedSize, logger.openedInfo, logger.lastCheckTime = 0, nil, time.Now()
+	logger.openedGeneration = atomic.LoadUint64(&reopenGeneration)
+	if fileInfo, err := logger.logFile.Stat(); err == nil {
+		logger.observedSize, logger.openedInfo = fileInfo.Size(), fileInfo
+	}
 	return logger.logFile, nil
 }
 
@@ -157,72 +280,49 @@ func (logger *FileWriter) rollLogFile() error {
 	}
 	fileInfo, err := os.Stat(logger.fileName)
 	if err != nil {
-		printError("can not get log file status: %s.", err.Error())
+		logger.reportError("rotate", err)
 		if os.IsNotExist(err) {
-			logger.logFile = nil //如果日志文件在写入过程中被人为删除，则促使生成下一文件
+			logger.logFile, logger.openedInfo = nil, nil //如果日志文件在写入过程中被人为删除，则促使生成下一文件
 		}
 		return err
 	}
-	//如果没有定义文件大小，则默认一个日志文件100M
-	rotateSize := logger.rotateSize
-	if rotateSize == 0 {
-		rotateSize = 1024 * 1024 * 100
-	}
-	if fileInfo.Size() < logger.rotateSize {
+	if fileInfo.Size() < logger.getRotateSize() {
 		return nil
 	}
+	return logger.rotateLogFile()
+}
 
-	// 修改log文件命名 liwenqiao 2017-7-20
-	fileExt := filepath.Ext(logger.fileName)
-	fileNameWithoutExt := strings.TrimSuffix(logger.fileName, fileExt)
-	newLogFileName := fileNameWithoutExt + "." + time.Now().Format("20060102_150405.000") + fileExt
-	err = logger.RenameCurLogFile(newLogFileName)
-	if err != nil {
-		return err
+/**
+ * 获取日志文件滚动的大小，如果没有定义文件大小，则默认一个日志文件100M
+ */
+func (logger *FileWriter) getRotateSize() int64 {
+	if logger.rotateSize == 0 {
+		return 1024 * 1024 * 100
 	}
-	return nil
+	return logger.rotateSize
 }
 
 /**
- * 清理过期日志
+ * 关闭并滚动当前日志文件（调用方需持有写入锁）
  */
-func (logger *FileWriter) deleteExpiredLogFile() error {
-	tempFileInfos, err := ioutil.ReadDir(filepath.Dir(logger.fileName))
-	if err != nil {
-		printError("can not get file infos : %s.", err.Error())
-		return err
-	}
-	// 从所有文件中选择出.log文件，保存到fileInfos中
-	fileInfos := make(SortableFileArr, 0, len(tempFileInfos))
-	fileExt := filepath.Ext(logger.fileName) // fileExt == ".log"
-	fileNameItems := strings.Split(logger.fileName, "/")
-	fileName := fileNameItems[len(fileNameItems)-1]
-	fileName = strings.Replace(fileName, fileExt, "", -1) + "."
-	for i := 0; i < len(tempFileInfos); i++ {
-		if !strings.HasPrefix(tempFileInfos[i].Name(), fileName) {
-			continue
-		}
-		if !strings.HasSuffix(tempFileInfos[i].Name(), fileExt) {
-			continue
+func (logger *FileWriter) rotateLogFile() (err error) {
+	logger.closeFile()
+	if logger.currentLink {
+		//软链接模式下当前分段即为历史文件，立即创建新分段并更新软链接，保证保留策略不会把新的当前分段当作历史文件
+		if logger.segmentPath, err = logger.newSegmentPath(); err == nil {
+			_, err = logger.getLoggingFile()
 		}
-		fileInfos = append(fileInfos, tempFileInfos[i])
-	}
-	if len(fileInfos) == 1 {
-		return nil //如果只有一个文件，则不删除，避免当前日志文件正处于写入状态
-	}
-	// 小于reserveNum时，不需要清理
-	if len(fileInfos) <= logger.logFileReserveNum {
-		return nil
+	} else {
+		//按命名方式将当前文件重命名为历史文件，下次写入时重新创建当前文件
+		err = logger.getNamingScheme().Rotate(logger.fileName, time.Now())
 	}
-	sort.Sort(fileInfos)
-	fileDir, _ := filepath.Split(logger.fileName)
-	for i := 0; i < len(fileInfos)-logger.logFileReserveNum; i++ { //TODO: 逻辑是不是反了？
-		fileName := fileDir + fileInfos[i].Name()
-		err = os.Remove(fileName)
-		if err != nil {
-			printError("can not remove file : <%s> %s.", fileName, err.Error())
-		}
+	if err != nil {
+		logger.reportError("rotate", err)
+		return err
 	}
+	atomic.AddUint64(&logger.rotations, 1)
+	//滚动后立即按保留策略清理一次，不必等到下次定时检查
+	logger.applyRetention()
 	return nil
 }
 
@@ -230,35 +330,78 @@ func (logger *FileWriter) deleteExpiredLogFile() error {
  * 重命名当前日志文件（例如在滚动日志文件时）
  */
 func (logger *FileWriter) RenameCurLogFile(newFileName string) error {
-	logger.Close()
+	logger.writeLock.Lock()
+	defer logger.writeLock.Unlock()
+	return logger.renameCurLogFile(newFileName)
+}
+
+/**
+ * 关闭并重命名当前日志文件（调用方需持有写入锁）
+ */
+func (logger *FileWriter) renameCurLogFile(newFileName string) error {
+	logger.closeFile()
 	err := os.Rename(logger.fileName, newFileName)
 	if err != nil {
-		printError("can not rename log file (%s -> %s): %s.", logger.fileName, newFileName, err.Error())
+		logger.reportError("rename", err)
 		return err
 	}
 	return nil
 }
 
 /**
- * 关闭当前日志文件
+ * 关闭当前日志文件，调用过Init时先等待缓存队列中的日志全部写入文件
  */
-func (logger *FileWriter) Close() {
-	if logger.logFile != nil {
-		err := logger.logFile.Close()
-		if err != nil {
-			printError("can not close log file: %s.", err.Error())
-		}
-		logger.logFile = nil
+func (logger *FileWriter) Close() error {
+	if asyncWriter := logger.asyncWriter; asyncWriter != nil {
+		return asyncWriter.Close()
 	}
+	return logger.closeSync()
 }
 
 /**
- * 声明一个排序数组，用于对文件名排序
+ * 在当前协程中关闭日志文件
  */
-type SortableFileArr []os.FileInfo
+func (logger *FileWriter) closeSync() error {
+	logger.writeLock.Lock()
+	defer logger.writeLock.Unlock()
+	logger.stopRetentionTicker()
+	err := logger.closeFile()
+	logger.closeProcessLock()
+	return err
+}
 
-func (s SortableFileArr) Less(i, j int) bool { return s[i].ModTime().Before(s[j].ModTime()) }
+/**
+ * 关闭当前日志文件句柄（调用方需持有写入锁），下次写入时重新打开
+ */
+func (logger *FileWriter) closeFile() error {
+	if logger.logFile == nil {
+		return nil
+	}
+	err := logger.logFile.Close()
+	if err != nil {
+		logger.reportError("close", err)
+	}
+	logger.logFile, logger.openedInfo = nil, nil
+	return err
+}
 
-func (s SortableFileArr) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+/**
+ * Init启用的后台协程使用的内部书写器，在后台协程中同步写入文件
+ */
+type syncFileWriter struct {
+	file *FileWriter
+}
+
+func (writer syncFileWriter) WriteLog(msg *LogMsg) error {
+	return writer.file.writeLogSync(msg)
+}
 
-func (s SortableFileArr) Len() int { return len(s) }
+func (writer syncFileWriter) Close() error {
+	return writer.file.closeSync()
+}
+
+/**
+ * 文件书写器自行报告错误，异步队列不再重复报告
+ */
+func (writer syncFileWriter) SetErrorHandler(handler ErrorHandler) {
+}
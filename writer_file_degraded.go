@@ -0,0 +1,121 @@
+package loglet
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+/**
+ * 降级状态下重试写入文件的初始间隔及最大间隔，每次重试失败后间隔加倍
+ */
+const (
+	minDegradedRetryInterval = time.Second
+	maxDegradedRetryInterval = time.Minute
+)
+
+/**
+ * 写入错误的最短报告间隔，间隔内的错误只计数，在下次报告时一并说明
+ */
+const errorReportInterval = 10 * time.Second
+
+/**
+ * 设置降级时的备用书写器：文件无法写入（磁盘已满、无权限、只读文件系统等）时日志改为写入备用书写器，
+ * 未设置时这些日志被丢弃并计入丢弃数
+ */
+func (logger *FileWriter) SetFallbackWriter(fallback LogWriter) {
+	logger.writeLock.Lock()
+	defer logger.writeLock.Unlock()
+	logger.fallback = fallback
+}
+
+/**
+ * 判断文件日志书写器是否处于降级状态
+ */
+func (logger *FileWriter) IsDegraded() bool {
+	return atomic.LoadUint32(&logger.degraded) == 1
+}
+
+/**
+ * 判断是否为短时间内不会自行恢复的写入错误
+ */
+func isPersistentWriteError(err error) bool {
+	for _, errno := range persistentWriteErrnos {
+		if errors.Is(err, errno) {
+			return true
+		}
+	}
+	return os.IsPermission(err)
+}
+
+/**
+ * 降级状态下尚未到重试时间时返回true，日志直接交给备用书写器（调用方需持有写入锁）
+ */
+func (logger *FileWriter) skipDegradedWrite(msg *LogMsg) bool {
+	if !logger.IsDegraded() || !time.Now().Before(logger.nextRetryTime) {
+		return false
+	}
+	logger.writeFallback(msg)
+	return true
+}
+
+/**
+ * 处理写入失败（调用方需持有写入锁）：持久性错误使书写器进入降级状态并延长重试间隔，失败的日志交给备用书写器
+ */
+func (logger *FileWriter) handleWriteError(msg *LogMsg, operation string, err error) {
+	atomic.AddUint64(&logger.errors, 1)
+	if isPersistentWriteError(err) {
+		now := time.Now()
+		if !logger.IsDegraded() {
+			atomic.StoreUint32(&logger.degraded, 1)
+			logger.retryInterval = minDegradedRetryInterval
+		} else if logger.retryInterval *= 2; logger.retryInterval > maxDegradedRetryInterval {
+			logger.retryInterval = maxDegradedRetryInterval
+		}
+		logger.nextRetryTime = now.Add(logger.retryInterval)
+		//关闭文件，重试时重新打开（例如文件系统重新挂载为可写之后）
+		logger.closeFile()
+	}
+	logger.writeFallback(msg)
+	logger.reportWriteError(operation, err)
+}
+
+/**
+ * 将日志交给备用书写器，未设置备用书写器或备用书写器写入失败时计入丢弃数（调用方需持有写入锁）
+ */
+func (logger *FileWriter) writeFallback(msg *LogMsg) {
+	if logger.fallback == nil || logger.fallback.WriteLog(msg) != nil {
+		atomic.AddUint64(&logger.drops, 1)
+	}
+}
+
+/**
+ * 按报告间隔限流报告写入错误（调用方需持有写入锁）
+ */
+func (logger *FileWriter) reportWriteError(operation string, err error) {
+	now := time.Now()
+	if now.Sub(logger.lastErrorReport) < errorReportInterval {
+		logger.suppressedErrors++
+		return
+	}
+	if logger.suppressedErrors > 0 {
+		err = fmt.Errorf("%w (%d similar errors suppressed)", err, logger.suppressedErrors)
+	}
+	//降级状态在写入错误中一并说明，不单独报告
+	if logger.IsDegraded() {
+		err = fmt.Errorf("%w (degraded, retry in %s)", err, logger.retryInterval)
+	}
+	logger.reportError(operation, err)
+	logger.lastErrorReport, logger.suppressedErrors = now, 0
+}
+
+/**
+ * 写入成功后退出降级状态（调用方需持有写入锁），恢复不是错误，不通过错误处理函数报告，可以通过IsDegraded获取状态
+ */
+func (logger *FileWriter) recoverFromDegraded() {
+	if logger.IsDegraded() {
+		atomic.StoreUint32(&logger.degraded, 0)
+	}
+}
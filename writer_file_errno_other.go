@@ -0,0 +1,9 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package loglet
+
+/**
+ * 其他平台的错误码不统一，只通过os.IsPermission识别无权限错误
+ */
+var persistentWriteErrnos []error
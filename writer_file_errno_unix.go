@@ -0,0 +1,13 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package loglet
+
+import (
+	"syscall"
+)
+
+/**
+ * 短时间内不会自行恢复的写入错误：磁盘已满、超出配额、无权限、只读文件系统
+ */
+var persistentWriteErrnos = []error{syscall.ENOSPC, syscall.EDQUOT, syscall.EACCES, syscall.EPERM, syscall.EROFS}
@@ -0,0 +1,100 @@
+package loglet
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+/**
+ * 可以直接生成分段文件名的命名方式（时间戳、日期命名），软链接模式下直接向分段文件写入
+ */
+type segmentNamer interface {
+	SegmentPath(fileName string, segmentTime time.Time) string
+}
+
+/**
+ * 命名方式不支持软链接模式（例如数字序号命名需要移动已有的文件）
+ */
+var errLinkNotSupported = errors.New("naming scheme does not support current link")
+
+/**
+ * 设置是否启用软链接模式：日志直接写入按命名方式生成的分段文件，日志文件基名作为指向当前分段的软链接，
+ * 每次滚动时原子地更新软链接，便于tail -F及日志采集工具始终跟随当前文件
+ */
+func (logger *FileWriter) SetCurrentLink(enabled bool) error {
+	logger.writeLock.Lock()
+	defer logger.writeLock.Unlock()
+	if _, ok := logger.getNamingScheme().(segmentNamer); enabled && !ok {
+		return errLinkNotSupported
+	}
+	logger.currentLink = enabled
+	logger.segmentPath = ""
+	return nil
+}
+
+/**
+ * 获取软链接模式下当前要写入的分段文件路径（调用方需持有写入锁）
+ * 启动时软链接有效则继续写入其指向的分段；软链接失效或不存在时创建新分段；基名处为普通文件时将其转为分段
+ */
+func (logger *FileWriter) getSegmentPath() (string, error) {
+	if logger.segmentPath != "" {
+		return logger.segmentPath, nil
+	}
+	namer, ok := logger.getNamingScheme().(segmentNamer)
+	if !ok {
+		return "", errLinkNotSupported
+	}
+	linkInfo, err := os.Lstat(logger.fileName)
+	switch {
+	case err == nil && linkInfo.Mode()&os.ModeSymlink != 0:
+		if target, err := filepath.EvalSymlinks(logger.fileName); err == nil {
+			logger.segmentPath = target
+			return target, nil
+		}
+		//软链接指向的文件已不存在，创建新分段
+	case err == nil:
+		//非软链接模式下遗留的当前文件，转为分段后继续写入
+		segmentPath := namer.SegmentPath(logger.fileName, time.Now())
+		if err = os.Rename(logger.fileName, segmentPath); err != nil {
+			return "", err
+		}
+		logger.segmentPath = segmentPath
+		return segmentPath, nil
+	}
+	logger.segmentPath = namer.SegmentPath(logger.fileName, time.Now())
+	return logger.segmentPath, nil
+}
+
+/**
+ * 滚动时生成新分段的文件路径（调用方需持有写入锁）
+ */
+func (logger *FileWriter) newSegmentPath() (string, error) {
+	namer, ok := logger.getNamingScheme().(segmentNamer)
+	if !ok {
+		return "", errLinkNotSupported
+	}
+	return namer.SegmentPath(logger.fileName, time.Now()), nil
+}
+
+/**
+ * 将软链接原子地指向指定分段：先创建临时软链接，再通过重命名替换原有的软链接
+ */
+func repointLink(linkPath string, targetPath string) error {
+	//软链接使用相对路径，日志目录整体移动后仍然有效
+	target := filepath.Base(targetPath)
+	if current, err := os.Readlink(linkPath); err == nil && current == target {
+		return nil
+	}
+	tempLink := linkPath + ".link.tmp"
+	os.Remove(tempLink)
+	if err := os.Symlink(target, tempLink); err != nil {
+		return err
+	}
+	if err := os.Rename(tempLink, linkPath); err != nil {
+		os.Remove(tempLink)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package loglet
+
+import (
+	"os"
+)
+
+/**
+ * 当前平台不支持flock文件锁
+ */
+const fileLockSupported = false
+
+/**
+ * 当前平台不支持文件锁，始终返回错误
+ */
+func lockFile(file *os.File) error {
+	return errFileLockNotSupported
+}
+
+/**
+ * 当前平台不支持文件锁，始终返回错误
+ */
+func unlockFile(file *os.File) error {
+	return errFileLockNotSupported
+}
@@ -0,0 +1,33 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package loglet
+
+import (
+	"os"
+	"syscall"
+)
+
+/**
+ * 当前平台支持flock文件锁
+ */
+const fileLockSupported = true
+
+/**
+ * 获取文件的排他锁，阻塞直到获取成功
+ */
+func lockFile(file *os.File) error {
+	for {
+		err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX)
+		if err != syscall.EINTR {
+			return err
+		}
+	}
+}
+
+/**
+ * 释放文件锁
+ */
+func unlockFile(file *os.File) error {
+	return syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+}
@@ -0,0 +1,69 @@
+package loglet
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+/**
+ * 当前平台不支持文件锁时返回的错误
+ */
+var errFileLockNotSupported = errors.New("multi-process file lock is not supported on this platform")
+
+/**
+ * 设置是否启用多进程模式：多个进程向同一路径写日志时，通过建议性文件锁（flock）协调写入、滚动及清理，
+ * 并在每次写入前检查文件是否已被其他进程滚动，是则重新打开新文件（不支持文件锁的平台返回错误）
+ */
+func (logger *FileWriter) SetMultiProcess(enabled bool) error {
+	if enabled && !fileLockSupported {
+		return errFileLockNotSupported
+	}
+	logger.writeLock.Lock()
+	defer logger.writeLock.Unlock()
+	logger.multiProcess = enabled
+	if !enabled {
+		logger.closeProcessLock()
+	}
+	return nil
+}
+
+/**
+ * 获取进程间文件锁（调用方需持有写入锁），锁文件为base.ext.lock，首次使用时创建
+ */
+func (logger *FileWriter) lockProcess() error {
+	if logger.processLock == nil {
+		lockPath := logger.fileName + ".lock"
+		if err := os.MkdirAll(filepath.Dir(lockPath), 0777); err != nil {
+			return err
+		}
+		file, err := os.OpenFile(lockPath, os.O_RDWR|os.O_CREATE, 0644)
+		if err != nil {
+			return err
+		}
+		logger.processLock = file
+	}
+	return lockFile(logger.processLock)
+}
+
+/**
+ * 释放进程间文件锁（调用方需持有写入锁）
+ */
+func (logger *FileWriter) unlockProcess() {
+	if logger.processLock == nil {
+		return
+	}
+	if err := unlockFile(logger.processLock); err != nil {
+		logger.reportError("lock", err)
+	}
+}
+
+/**
+ * 关闭锁文件（调用方需持有写入锁）
+ */
+func (logger *FileWriter) closeProcessLock() {
+	if logger.processLock != nil {
+		logger.processLock.Close()
+		logger.processLock = nil
+	}
+}
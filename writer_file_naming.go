@@ -0,0 +1,317 @@
+package loglet
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/**
+ * 内置的滚动文件命名方式
+ */
+const (
+	NAMING_TIMESTAMP = "timestamp" //app.20060102_150405.000.log（默认）
+	NAMING_NUMBERED  = "numbered"  //app.log.1、app.log.2……，与logrotate一致，数字越大越旧
+	NAMING_DATE      = "date"      //app-2006-01-02.log，同一天多次滚动时为app-2006-01-02.1.log……
+)
+
+/**
+ * 滚动文件命名方式抽象定义，文件滚动、保留策略及命令行工具通过它识别历史文件
+ */
+type NamingScheme interface {
+	Rotate(fileName string, rotateTime time.Time) error //将当前日志文件（已关闭）重命名为历史文件
+	Segments(fileName string) ([]LogSegment, error)     //查找所有分段，按时间先后排序，当前文件排在最后
+}
+
+/**
+ * 根据名称获取命名方式，名称为空时使用默认的时间戳命名
+ */
+func NamingSchemeByName(name string) (NamingScheme, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", NAMING_TIMESTAMP:
+		return TimestampNaming{}, nil
+	case NAMING_NUMBERED:
+		return NumberedNaming{}, nil
+	case NAMING_DATE:
+		return DateNaming{}, nil
+	default:
+		return nil, fmt.Errorf("unknown file naming scheme: %s", name)
+	}
+}
+
+/**
+ * 时间戳命名：base.20060102_150405.000.ext
+ */
+type TimestampNaming struct {
+}
+
+/**
+ * 以滚动时间重命名当前日志文件
+ */
+func (naming TimestampNaming) Rotate(fileName string, rotateTime time.Time) error {
+	return os.Rename(fileName, naming.SegmentPath(fileName, rotateTime))
+}
+
+/**
+ * 生成指定时间尚未使用的分段文件路径，同一毫秒内多次滚动时依次顺延1毫秒，避免覆盖已有的分段
+ */
+func (naming TimestampNaming) SegmentPath(fileName string, segmentTime time.Time) string {
+	fileExt := filepath.Ext(fileName)
+	fileNameWithoutExt := strings.TrimSuffix(fileName, fileExt)
+	segmentPath := fileNameWithoutExt + "." + segmentTime.Format(rotateTimeLayout) + fileExt
+	for isSegmentExists(segmentPath) {
+		segmentTime = segmentTime.Add(time.Millisecond)
+		segmentPath = fileNameWithoutExt + "." + segmentTime.Format(rotateTimeLayout) + fileExt
+	}
+	return segmentPath
+}
+
+/**
+ * 查找时间戳命名的分段，按文件名中的时间排序
+ */
+func (naming TimestampNaming) Segments(fileName string) ([]LogSegment, error) {
+	fileExt := filepath.Ext(fileName)
+	namePrefix := strings.TrimSuffix(filepath.Base(fileName), fileExt) + "."
+	history, active, err := listLogSegments(fileName, func(name string, segment *LogSegment) bool {
+		if !strings.HasPrefix(name, namePrefix) || !strings.HasSuffix(name, fileExt) {
+			return false
+		}
+		timeStr := strings.TrimSuffix(strings.TrimPrefix(name, namePrefix), fileExt)
+		segmentTime, err := time.ParseInLocation(rotateTimeLayout, timeStr, time.Local)
+		if err != nil {
+			return false //不是滚动产生的文件
+		}
+		segment.Time = segmentTime
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].Time.Before(history[j].Time)
+	})
+	return appendActiveSegment(history, active), nil
+}
+
+/**
+ * 数字序号命名：base.ext.1为最近一次滚动的文件，每次滚动时已有的序号依次加一
+ */
+type NumberedNaming struct {
+}
+
+/**
+ * 依次后移已有的历史文件序号，再将当前日志文件重命名为base.ext.1
+ */
+func (naming NumberedNaming) Rotate(fileName string, rotateTime time.Time) error {
+	segments, err := naming.Segments(fileName)
+	if err != nil {
+		return err
+	}
+	//segments从旧到新排列，即序号从大到小，按此顺序后移不会覆盖尚未移动的文件
+	for _, segment := range segments {
+		if segment.Active {
+			continue
+		}
+		number := getSegmentNumber(fileName, segment)
+		newPath := fileName + "." + strconv.Itoa(number+1)
+		if segment.Compressed {
+			newPath += compressedExt
+		}
+		if err = os.Rename(segment.Path, newPath); err != nil {
+			return err
+		}
+	}
+	return os.Rename(fileName, fileName+".1")
+}
+
+/**
+ * 查找数字序号命名的分段，序号越大越旧；文件名中没有时间，分段时间取文件的修改时间
+ */
+func (naming NumberedNaming) Segments(fileName string) ([]LogSegment, error) {
+	history, active, err := listLogSegments(fileName, func(name string, segment *LogSegment) bool {
+		number, err := strconv.Atoi(strings.TrimPrefix(name, filepath.Base(fileName)+"."))
+		if err != nil || number < 1 || !strings.HasPrefix(name, filepath.Base(fileName)+".") {
+			return false
+		}
+		fileInfo, err := os.Stat(segment.Path)
+		if err != nil {
+			return false
+		}
+		segment.Time = fileInfo.ModTime()
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(history, func(i, j int) bool {
+		return getSegmentNumber(fileName, history[i]) > getSegmentNumber(fileName, history[j])
+	})
+	return appendActiveSegment(history, active), nil
+}
+
+/**
+ * 获取数字序号命名的分段序号
+ */
+func getSegmentNumber(fileName string, segment LogSegment) int {
+	name := strings.TrimSuffix(filepath.Base(segment.Path), compressedExt)
+	number, _ := strconv.Atoi(strings.TrimPrefix(name, filepath.Base(fileName)+"."))
+	return number
+}
+
+/**
+ * 日期命名：base-2006-01-02.ext，同一天多次滚动时依次为base-2006-01-02.1.ext、base-2006-01-02.2.ext……
+ */
+type DateNaming struct {
+}
+
+/**
+ * 以滚动日期重命名当前日志文件，当天已有滚动文件时追加序号
+ */
+func (naming DateNaming) Rotate(fileName string, rotateTime time.Time) error {
+	return os.Rename(fileName, naming.SegmentPath(fileName, rotateTime))
+}
+
+/**
+ * 生成指定日期尚未使用的分段文件路径
+ */
+func (naming DateNaming) SegmentPath(fileName string, segmentTime time.Time) string {
+	fileExt := filepath.Ext(fileName)
+	datePrefix := strings.TrimSuffix(fileName, fileExt) + "-" + segmentTime.Format("2006-01-02")
+	segmentPath := datePrefix + fileExt
+	for number := 1; isSegmentExists(segmentPath); number++ {
+		segmentPath = datePrefix + "." + strconv.Itoa(number) + fileExt
+	}
+	return segmentPath
+}
+
+/**
+ * 查找日期命名的分段，按日期及当天的序号排序
+ */
+func (naming DateNaming) Segments(fileName string) ([]LogSegment, error) {
+	fileExt := filepath.Ext(fileName)
+	namePrefix := strings.TrimSuffix(filepath.Base(fileName), fileExt) + "-"
+	numbers := make(map[string]int)
+	history, active, err := listLogSegments(fileName, func(name string, segment *LogSegment) bool {
+		if !strings.HasPrefix(name, namePrefix) || !strings.HasSuffix(name, fileExt) {
+			return false
+		}
+		dateStr := strings.TrimSuffix(strings.TrimPrefix(name, namePrefix), fileExt)
+		number := 0
+		if dotIndex := strings.IndexByte(dateStr, '.'); dotIndex >= 0 {
+			var err error
+			if number, err = strconv.Atoi(dateStr[dotIndex+1:]); err != nil {
+				return false
+			}
+			dateStr = dateStr[:dotIndex]
+		}
+		segmentTime, err := time.ParseInLocation("2006-01-02", dateStr, time.Local)
+		if err != nil {
+			return false
+		}
+		segment.Time = segmentTime
+		numbers[segment.Path] = number
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(history, func(i, j int) bool {
+		if !history[i].Time.Equal(history[j].Time) {
+			return history[i].Time.Before(history[j].Time)
+		}
+		return numbers[history[i].Path] < numbers[history[j].Path]
+	})
+	return appendActiveSegment(history, active), nil
+}
+
+/**
+ * 列出日志文件所在目录中的分段，parse判断文件名（已去掉压缩扩展名）是否为历史文件并填充分段时间
+ */
+func listLogSegments(fileName string, parse func(name string, segment *LogSegment) bool) ([]LogSegment, *LogSegment, error) {
+	fileDir := filepath.Dir(fileName)
+	fileInfos, err := ioutil.ReadDir(fileDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	baseName := filepath.Base(fileName)
+	history := make([]LogSegment, 0, len(fileInfos))
+	var active *LogSegment
+	linkTarget := ""
+	for _, fileInfo := range fileInfos {
+		if fileInfo.IsDir() {
+			continue
+		}
+		name := fileInfo.Name()
+		if name == baseName {
+			//软链接模式下基名指向当前分段，以其指向的文件作为当前文件
+			if fileInfo.Mode()&os.ModeSymlink != 0 {
+				linkTarget, _ = filepath.EvalSymlinks(fileName)
+				continue
+			}
+			active = &LogSegment{Path: filepath.Join(fileDir, name), Active: true}
+			continue
+		}
+		segment := LogSegment{Path: filepath.Join(fileDir, name)}
+		if strings.HasSuffix(name, compressedExt) {
+			segment.Compressed = true
+			name = strings.TrimSuffix(name, compressedExt)
+		}
+		if parse(name, &segment) {
+			history = append(history, segment)
+		}
+	}
+	if linkTarget != "" {
+		active = &LogSegment{Path: linkTarget, Active: true}
+		for i, segment := range history {
+			if isSameFile(segment.Path, linkTarget) {
+				segment.Active = true
+				active = &segment
+				history = append(history[:i], history[i+1:]...)
+				break
+			}
+		}
+	}
+	return history, active, nil
+}
+
+/**
+ * 将当前文件追加到分段列表末尾
+ */
+func appendActiveSegment(segments []LogSegment, active *LogSegment) []LogSegment {
+	if active != nil {
+		segments = append(segments, *active)
+	}
+	return segments
+}
+
+/**
+ * 判断两个路径是否指向同一个文件
+ */
+func isSameFile(path1 string, path2 string) bool {
+	fileInfo1, err := os.Stat(path1)
+	if err != nil {
+		return false
+	}
+	fileInfo2, err := os.Stat(path2)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(fileInfo1, fileInfo2)
+}
+
+/**
+ * 判断分段文件（或其压缩文件）是否已存在
+ */
+func isSegmentExists(path string) bool {
+	for _, candidate := range []string{path, path + compressedExt} {
+		if _, err := os.Stat(candidate); err == nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+package loglet
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+/**
+ * 默认检查文件是否被外部移走或截断的周期
+ */
+const defaultReopenCheckInterval = time.Second
+
+/**
+ * 重新打开文件的通知次数（atomic读写），文件书写器打开文件时记录该值，写入时发现该值变化则重新打开文件；
+ * 不登记文件书写器，避免未调用Close的书写器无法被回收
+ */
+var reopenGeneration uint64
+
+/**
+ * 设置检查文件是否被外部移走、截断或删除的周期（默认1秒），多进程模式下每次写入都会检查；
+ * 截断通过文件变小判断，如果截断后在一个检查周期内文件又增长到超过截断前的大小，则无法发现此次截断
+ */
+func (logger *FileWriter) SetReopenCheckInterval(interval time.Duration) {
+	logger.writeLock.Lock()
+	defer logger.writeLock.Unlock()
+	logger.reopenInterval = interval
+}
+
+/**
+ * 获取检查文件的周期
+ */
+func (logger *FileWriter) getReopenCheckInterval() time.Duration {
+	if logger.reopenInterval <= 0 {
+		return defaultReopenCheckInterval
+	}
+	return logger.reopenInterval
+}
+
+/**
+ * 关闭当前文件，下次写入时按日志路径重新打开（例如外部logrotate移走文件之后）
+ */
+func (logger *FileWriter) Reopen() {
+	logger.writeLock.Lock()
+	defer logger.writeLock.Unlock()
+	logger.closeFile()
+	logger.segmentPath = ""
+}
+
+/**
+ * 当前打开的文件已不是日志路径指向的文件（被移走、删除或被其他进程滚动），或者文件被截断时关闭它，
+ * 下次写入时重新打开（调用方需持有写入锁）
+ */
+func (logger *FileWriter) reopenIfChanged() {
+	logger.lastCheckTime = time.Now()
+	if logger.logFile == nil {
+		return
+	}
+	if logger.openedInfo == nil {
+		return
+	}
+	//打开文件时已记录了文件信息，只需获取日志路径当前指向的文件；软链接模式下os.Stat跟随软链接，得到的是其他进程滚动后的当前分段
+	currentInfo, err := os.Stat(logger.fileName)
+	if err == nil && os.SameFile(logger.openedInfo, currentInfo) {
+		if currentInfo.Size() >= logger.observedSize {
+			logger.observedSize = currentInfo.Size()
+			return
+		}
+		//文件变小说明被截断（例如logrotate的copytruncate），重新打开以便从头写入
+	}
+	logger.closeFile()
+	logger.segmentPath = ""
+}
+
+/**
+ * 打开文件之后收到过重新打开的通知时关闭当前文件，下次写入时重新打开（调用方需持有写入锁）
+ */
+func (logger *FileWriter) reopenIfRequested() {
+	if logger.logFile != nil && logger.openedGeneration != atomic.LoadUint64(&reopenGeneration) {
+		logger.closeFile()
+		logger.segmentPath = ""
+	}
+}
+
+/**
+ * 通知所有文件日志书写器重新打开文件，各书写器在下一次写入时关闭旧文件并按日志路径重新打开
+ */
+func ReopenAllFiles() {
+	atomic.AddUint64(&reopenGeneration, 1)
+}
+
+/**
+ * 收到指定信号时重新打开所有文件日志书写器的文件，未指定信号时使用平台默认信号（类Unix平台为SIGHUP、SIGUSR1），
+ * 返回的函数用于停止处理信号
+ */
+func HandleReopenSignals(signals ...os.Signal) func() {
+	if len(signals) == 0 {
+		signals = defaultReopenSignals
+	}
+	if len(signals) == 0 {
+		return func() {}
+	}
+	stop := make(chan struct{})
+	signalChan := make(chan os.Signal, 1)
+	signal.Notify(signalChan, signals...)
+	go func() {
+		for {
+			select {
+			case <-signalChan:
+				ReopenAllFiles()
+			case <-stop:
+				return
+			}
+		}
+	}()
+	var stopOnce sync.Once
+	return func() {
+		stopOnce.Do(func() {
+			signal.Stop(signalChan)
+			close(stop)
+		})
+	}
+}
+
+/**
+ * 通过Init配置开启信号处理时只注册一次
+ */
+var reopenSignalsOnce sync.Once
+
+/**
+ * 根据Init配置开启信号处理（reopen_on_signal=true），整个进程只注册一次
+ */
+func handleReopenSignalsOnce() {
+	reopenSignalsOnce.Do(func() {
+		HandleReopenSignals()
+	})
+}
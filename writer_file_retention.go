@@ -0,0 +1,186 @@
+package loglet
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+/**
+ * 历史日志文件的保留策略，各条件可以任意组合，为零值的条件不生效
+ */
+type RetentionPolicy struct {
+	MaxAge       time.Duration //历史文件的最长保留时间（按文件名中的滚动时间计算，数字序号命名时按文件修改时间计算）
+	MaxTotalSize int64         //当前文件与历史文件的总大小上限（字节），超出时从最早的历史文件开始删除
+	MaxFiles     int           //保留历史文件的个数，不含当前文件
+}
+
+/**
+ * 默认的保留策略检查周期
+ */
+const defaultRetentionInterval = time.Minute
+
+/**
+ * 设置历史日志文件的保留策略
+ */
+func (logger *FileWriter) SetRetentionPolicy(policy RetentionPolicy) {
+	logger.writeLock.Lock()
+	defer logger.writeLock.Unlock()
+	logger.retention = policy
+}
+
+/**
+ * 设置保留策略的检查周期（默认1分钟），文件滚动时也会立即检查一次
+ */
+func (logger *FileWriter) SetRetentionInterval(interval time.Duration) {
+	logger.writeLock.Lock()
+	defer logger.writeLock.Unlock()
+	logger.retentionInterval = interval
+}
+
+/**
+ * 启动定时执行保留策略的协程（调用方需持有写入锁），文件关闭时停止
+ */
+func (logger *FileWriter) startRetentionTicker() {
+	if logger.retentionStop != nil {
+		return
+	}
+	interval := logger.retentionInterval
+	if interval <= 0 {
+		interval = defaultRetentionInterval
+	}
+	stop := make(chan struct{})
+	logger.retentionStop = stop
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				logger.writeLock.Lock()
+				logger.applyRetentionLocked()
+				logger.writeLock.Unlock()
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+/**
+ * 定时执行保留策略（调用方需持有写入锁），多进程模式下同时持有进程间文件锁，避免多个进程同时清理
+ */
+func (logger *FileWriter) applyRetentionLocked() {
+	if logger.multiProcess {
+		if err := logger.lockProcess(); err != nil {
+			logger.reportError("lock", err)
+			return
+		}
+		defer logger.unlockProcess()
+	}
+	logger.applyRetention()
+}
+
+/**
+ * 停止定时执行保留策略的协程（调用方需持有写入锁）
+ */
+func (logger *FileWriter) stopRetentionTicker() {
+	if logger.retentionStop != nil {
+		close(logger.retentionStop)
+		logger.retentionStop = nil
+	}
+}
+
+/**
+ * 按保留策略清理历史日志文件（调用方需持有写入锁），历史文件按命名方式排序，当前文件永远不会被删除
+ */
+func (logger *FileWriter) applyRetention() {
+	policy := logger.retention
+	if logger.fileName == "" || policy.MaxAge <= 0 && policy.MaxTotalSize <= 0 && policy.MaxFiles <= 0 {
+		return
+	}
+	segments, err := logger.getNamingScheme().Segments(logger.fileName)
+	if err != nil {
+		logger.reportError("retention", err)
+		return
+	}
+	var activeSize int64
+	history := make([]LogSegment, 0, len(segments))
+	for _, segment := range segments {
+		if segment.Active {
+			if fileInfo, err := os.Stat(segment.Path); err == nil {
+				activeSize = fileInfo.Size()
+			}
+			continue
+		}
+		history = append(history, segment)
+	}
+	//history按时间先后排序，从最早的文件开始判断是否需要删除
+	expireTime := time.Now().Add(-policy.MaxAge)
+	var totalSize int64
+	sizes := make([]int64, len(history))
+	for i, segment := range history {
+		if fileInfo, err := os.Stat(segment.Path); err == nil {
+			sizes[i] = fileInfo.Size()
+			totalSize += sizes[i]
+		}
+	}
+	totalSize += activeSize
+	for i, segment := range history {
+		remaining := len(history) - i
+		expired := policy.MaxAge > 0 && segment.Time.Before(expireTime)
+		tooMany := policy.MaxFiles > 0 && remaining > policy.MaxFiles
+		tooLarge := policy.MaxTotalSize > 0 && totalSize > policy.MaxTotalSize
+		if !expired && !tooMany && !tooLarge {
+			break
+		}
+		err = os.Remove(segment.Path)
+		if err != nil && !os.IsNotExist(err) {
+			logger.reportError("retention", err)
+			continue
+		}
+		//文件已被其他进程删除时不计入本进程的清理个数
+		totalSize -= sizes[i]
+		if err == nil {
+			atomic.AddUint64(&logger.deletions, 1)
+		}
+	}
+}
+
+/**
+ * 解析文件大小配置，支持K、M、G单位，未指定单位时按M计算（与max_size的历史行为一致）
+ */
+func parseByteSize(sizeStr string) (int64, error) {
+	sizeStr = strings.ToUpper(strings.TrimSpace(sizeStr))
+	sizeStr = strings.TrimSuffix(sizeStr, "B")
+	unit := int64(1024 * 1024)
+	switch {
+	case strings.HasSuffix(sizeStr, "K"):
+		unit = 1024
+	case strings.HasSuffix(sizeStr, "G"):
+		unit = 1024 * 1024 * 1024
+	}
+	size, err := strconv.ParseInt(strings.TrimRight(sizeStr, "KMG"), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return size * unit, nil
+}
+
+/**
+ * 解析保留时长配置，除time.ParseDuration支持的格式外，还支持以d为单位的天数（例如14d）
+ */
+func parseRetentionAge(ageStr string) (time.Duration, error) {
+	ageStr = strings.TrimSpace(ageStr)
+	if strings.HasSuffix(ageStr, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(ageStr, "d"))
+		if err != nil {
+			return 0, fmt.Errorf("invalid days: %s", ageStr)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(ageStr)
+}
@@ -0,0 +1,68 @@
+package loglet
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"time"
+)
+
+/**
+ * 滚动日志文件名中的时间格式：base.20060102_150405.000.ext
+ */
+const rotateTimeLayout = "20060102_150405.000"
+
+/**
+ * 压缩后的历史日志文件扩展名
+ */
+const compressedExt = ".gz"
+
+/**
+ * 日志文件分段定义（当前正在写入的文件或滚动产生的历史文件）
+ */
+type LogSegment struct {
+	Path       string    //分段文件路径
+	Time       time.Time //分段的滚动时间（从文件名中解析），当前文件为零值
+	Compressed bool      //是否为gzip压缩文件
+	Active     bool      //是否为当前正在写入的文件
+}
+
+/**
+ * 查找FileWriter以默认的时间戳命名方式为指定日志文件生成的所有分段（包括压缩过的），按时间先后排序，当前文件排在最后
+ * 使用其他命名方式时通过NamingScheme.Segments查找
+ */
+func FindLogSegments(fileName string) ([]LogSegment, error) {
+	return TimestampNaming{}.Segments(fileName)
+}
+
+/**
+ * 打开一个日志分段用于读取，压缩文件会被透明解压
+ */
+func OpenLogSegment(segment LogSegment) (io.ReadCloser, error) {
+	file, err := os.Open(segment.Path)
+	if err != nil {
+		return nil, err
+	}
+	if !segment.Compressed {
+		return file, nil
+	}
+	gzipReader, err := gzip.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &compressedSegmentReader{Reader: gzipReader, file: file}, nil
+}
+
+/**
+ * 压缩分段读取器，关闭时同时关闭解压器和底层文件
+ */
+type compressedSegmentReader struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (reader *compressedSegmentReader) Close() error {
+	reader.Reader.Close()
+	return reader.file.Close()
+}
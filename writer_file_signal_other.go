@@ -0,0 +1,13 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package loglet
+
+import (
+	"os"
+)
+
+/**
+ * 当前平台没有默认触发重新打开日志文件的信号，需要时显式传入
+ */
+var defaultReopenSignals []os.Signal
@@ -0,0 +1,14 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package loglet
+
+import (
+	"os"
+	"syscall"
+)
+
+/**
+ * 默认触发重新打开日志文件的信号，与常见的logrotate postrotate脚本一致
+ */
+var defaultReopenSignals = []os.Signal{syscall.SIGHUP, syscall.SIGUSR1}
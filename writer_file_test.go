@@ -2,8 +2,12 @@ package loglet
 
 import (
 	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -83,6 +87,30 @@ func TestMultiRoutineFileLog(t *testing.T) {
 	time.Sleep(time.Second)
 }
 
+func TestFileWriterInit(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "async.log")
+	logger := new(FileWriter)
+	logger.Init()
+	logger.SetFileBaseName(fileName)
+	if stats := logger.WriterStats(); stats.QueueCapacity != 10000 {
+		t.Errorf("Init should start the background writer: %+v", stats)
+	}
+	for i := 0; i < 100; i++ {
+		logger.WriteLog(&LogMsg{msgLevel: INFO, msgTime: time.Now(), msgContent: "msg-" + strconv.Itoa(i)})
+	}
+	//关闭时等待队列中的日志全部写入文件
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+	content, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(content), "\n"); lines != 100 {
+		t.Errorf("expected 100 lines after close, got %d", lines)
+	}
+}
+
 func TestFilePath(t *testing.T) {
 	fmt.Println(filepath.Dir("/var/log/log_test/test.log"))
 	fmt.Println(filepath.Dir("D:/log_test/test.log"))
@@ -91,3 +119,336 @@ func TestFilePath(t *testing.T) {
 	fmt.Println(logger.isPathExists("D:/log_test/"))
 	fmt.Println(logger.isPathExists("D:/log_test/test.log"))
 }
+
+func TestRetentionPolicy(t *testing.T) {
+	dir := t.TempDir()
+	fileName := filepath.Join(dir, "app.log")
+	now := time.Now()
+	var history []string
+	for _, age := range []time.Duration{96 * time.Hour, 72 * time.Hour, 48 * time.Hour, 2 * time.Hour, time.Hour} {
+		path := filepath.Join(dir, "app."+now.Add(-age).Format(rotateTimeLayout)+".log")
+		if err := ioutil.WriteFile(path, make([]byte, 100), 0644); err != nil {
+			t.Fatal(err)
+		}
+		history = append(history, path)
+	}
+	//修改最早文件的修改时间，保留策略应按文件名中的时间排序而不受影响
+	os.Chtimes(history[0], now, now)
+	if err := ioutil.WriteFile(fileName, make([]byte, 100), 0644); err != nil {
+		t.Fatal(err)
+	}
+	logger := new(FileWriter)
+	logger.SetFileBaseName(fileName)
+	defer logger.Close()
+	existing := func() int {
+		segments, _ := FindLogSegments(fileName)
+		return len(segments)
+	}
+
+	logger.SetRetentionPolicy(RetentionPolicy{MaxFiles: 4})
+	logger.applyRetention()
+	if _, err := os.Stat(history[0]); !os.IsNotExist(err) || existing() != 5 {
+		t.Errorf("the oldest segment should be removed by max files")
+	}
+	logger.SetRetentionPolicy(RetentionPolicy{MaxAge: 60 * time.Hour})
+	logger.applyRetention()
+	if existing() != 4 {
+		t.Errorf("segments older than max age should be removed, %d left", existing())
+	}
+	logger.SetRetentionPolicy(RetentionPolicy{MaxTotalSize: 150, MaxAge: time.Minute})
+	logger.applyRetention()
+	if _, err := os.Stat(fileName); err != nil || existing() != 1 {
+		t.Errorf("the active file should never be removed, %d left", existing())
+	}
+	if stats := logger.WriterStats(); stats.Deletions != 5 {
+		t.Errorf("unexpected deletions: %d", stats.Deletions)
+	}
+}
+
+func TestParseRetentionConfig(t *testing.T) {
+	for sizeStr, expected := range map[string]int64{"100": 100 << 20, "512K": 512 << 10, "5G": 5 << 30, "2mb": 2 << 20} {
+		if size, err := parseByteSize(sizeStr); err != nil || size != expected {
+			t.Errorf("parse size %s: expected %d, got %d (%v)", sizeStr, expected, size, err)
+		}
+	}
+	if _, err := parseByteSize("abc"); err == nil {
+		t.Error("invalid size should fail")
+	}
+	if age, err := parseRetentionAge("14d"); err != nil || age != 14*24*time.Hour {
+		t.Errorf("unexpected age: %v (%v)", age, err)
+	}
+	if age, err := parseRetentionAge("36h"); err != nil || age != 36*time.Hour {
+		t.Errorf("unexpected age: %v (%v)", age, err)
+	}
+}
+
+func TestNamingSchemes(t *testing.T) {
+	rotateTime := time.Date(2024, 1, 1, 10, 0, 0, 0, time.Local)
+	for name, expected := range map[string][]string{
+		NAMING_TIMESTAMP: {"app.20240101_100000.000.log", "app.20240101_100001.000.log", "app.20240101_100002.000.log", "app.log"},
+		NAMING_NUMBERED:  {"app.log.3.gz", "app.log.2", "app.log.1", "app.log"},
+		NAMING_DATE:      {"app-2024-01-01.log", "app-2024-01-01.1.log", "app-2024-01-01.2.log", "app.log"},
+	} {
+		scheme, err := NamingSchemeByName(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dir := t.TempDir()
+		fileName := filepath.Join(dir, "app.log")
+		for i := 0; i < 3; i++ {
+			ioutil.WriteFile(fileName, []byte(strconv.Itoa(i)), 0644)
+			if err = scheme.Rotate(fileName, rotateTime.Add(time.Duration(i)*time.Second)); err != nil {
+				t.Fatal(err)
+			}
+			//数字序号命名时模拟外部压缩最旧的文件，滚动时应保留压缩扩展名
+			if name == NAMING_NUMBERED && i == 0 {
+				os.Rename(fileName+".1", fileName+".1.gz")
+			}
+		}
+		ioutil.WriteFile(fileName, []byte("3"), 0644)
+		ioutil.WriteFile(filepath.Join(dir, "other.log"), nil, 0644)
+		segments, err := scheme.Segments(fileName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, segment := range segments {
+			names = append(names, filepath.Base(segment.Path))
+		}
+		if fmt.Sprint(names) != fmt.Sprint(expected) {
+			t.Errorf("%s: expected segments %v, got %v", name, expected, names)
+		}
+		if !segments[0].Compressed == (name == NAMING_NUMBERED) || !segments[3].Active {
+			t.Errorf("%s: unexpected segment flags: %+v", name, segments)
+		}
+	}
+	if _, err := NamingSchemeByName("weekly"); err == nil {
+		t.Error("unknown naming scheme should fail")
+	}
+}
+
+func TestCurrentLink(t *testing.T) {
+	dir := t.TempDir()
+	fileName := filepath.Join(dir, "app.log")
+	//非软链接模式遗留的当前文件应转为分段继续写入
+	ioutil.WriteFile(fileName, []byte("legacy\n"), 0644)
+	logger := new(FileWriter)
+	logger.SetFileBaseName(fileName)
+	if err := logger.SetCurrentLink(true); err != nil {
+		t.Fatal(err)
+	}
+	logger.SetRotateSize(1)
+	logger.SetRetentionPolicy(RetentionPolicy{MaxFiles: 1})
+	readCurrent := func() string {
+		content, _ := ioutil.ReadFile(fileName)
+		return string(content)
+	}
+	logger.WriteLog(&LogMsg{msgLevel: INFO, msgTime: time.Now(), msgContent: "first"})
+	if linkInfo, err := os.Lstat(fileName); err != nil || linkInfo.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("base name should be a symlink: %v", err)
+	}
+	if content := readCurrent(); !strings.HasPrefix(content, "legacy\n") || !strings.Contains(content, "first") {
+		t.Errorf("legacy file should be kept as the current segment: %q", content)
+	}
+	for i := 0; i < 3; i++ {
+		time.Sleep(2 * time.Millisecond)
+		logger.rollLogFile()
+		logger.WriteLog(&LogMsg{msgLevel: INFO, msgTime: time.Now(), msgContent: "after-" + strconv.Itoa(i)})
+	}
+	if content := readCurrent(); !strings.Contains(content, "after-2") || strings.Contains(content, "after-1") {
+		t.Errorf("link should point to the newest segment: %q", content)
+	}
+	segments, _ := FindLogSegments(fileName)
+	if len(segments) != 2 || !segments[1].Active || filepath.Base(segments[1].Path) == "app.log" {
+		t.Fatalf("link target should be the active segment: %+v", segments)
+	}
+	logger.Close()
+
+	//软链接失效时应创建新分段
+	os.Remove(segments[1].Path)
+	logger = new(FileWriter)
+	logger.SetFileBaseName(fileName)
+	logger.SetCurrentLink(true)
+	logger.WriteLog(&LogMsg{msgLevel: INFO, msgTime: time.Now(), msgContent: "restart"})
+	logger.Close()
+	if content := readCurrent(); !strings.Contains(content, "restart") || strings.Contains(content, "after") {
+		t.Errorf("stale link should be replaced: %q", content)
+	}
+
+	//数字序号命名不支持软链接模式，两种设置顺序都应被拒绝
+	if err := logger.SetNamingScheme(NumberedNaming{}); err == nil {
+		t.Error("numbered naming should be rejected while current link is enabled")
+	}
+	logger.SetCurrentLink(false)
+	if err := logger.SetNamingScheme(NumberedNaming{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := logger.SetCurrentLink(true); err == nil {
+		t.Error("numbered naming should not support current link")
+	}
+}
+
+func TestMultiProcessFileWriter(t *testing.T) {
+	if !fileLockSupported {
+		t.Skip("file lock is not supported on this platform")
+	}
+	fileName := filepath.Join(t.TempDir(), "app.log")
+	//同一文件的两次打开各自持有独立的flock，可以模拟两个进程
+	var wg sync.WaitGroup
+	for w := 0; w < 2; w++ {
+		logger := new(FileWriter)
+		logger.SetFileBaseName(fileName)
+		logger.SetRotateSize(4096)
+		if err := logger.SetMultiProcess(true); err != nil {
+			t.Fatal(err)
+		}
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			defer logger.Close()
+			for i := 0; i < 500; i++ {
+				logger.WriteLog(&LogMsg{msgLevel: INFO, msgTime: time.Now(), targetPoint: "a.go 1 main.main() [1]", msgContent: fmt.Sprintf("worker-%d-%d", worker, i)})
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	segments, err := FindLogSegments(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(segments) < 3 {
+		t.Fatalf("expected rotation to happen, got %d segments", len(segments))
+	}
+	seen := make(map[string]bool)
+	for _, segment := range segments {
+		content, _ := ioutil.ReadFile(segment.Path)
+		for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+			msg, err := ParseLine([]byte(line))
+			if err != nil {
+				t.Fatalf("corrupted line in %s: %q", segment.Path, line)
+			}
+			if seen[msg.Content()] {
+				t.Errorf("duplicated line: %s", msg.Content())
+			}
+			seen[msg.Content()] = true
+		}
+	}
+	if len(seen) != 1000 {
+		t.Errorf("expected 1000 lines, got %d", len(seen))
+	}
+}
+
+func TestMultiProcessDefaultRotateSize(t *testing.T) {
+	if !fileLockSupported {
+		t.Skip("file lock is not supported on this platform")
+	}
+	//未设置滚动大小时使用默认的100M，多进程模式下每次写入都检查滚动，不应每条日志滚动一次
+	fileName := filepath.Join(t.TempDir(), "app.log")
+	logger := new(FileWriter)
+	logger.SetFileBaseName(fileName)
+	if err := logger.SetMultiProcess(true); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		logger.WriteLog(&LogMsg{msgLevel: INFO, msgTime: time.Now(), targetPoint: "a.go 1 main.main() [1]", msgContent: "msg-" + strconv.Itoa(i)})
+	}
+	logger.Close()
+	segments, err := FindLogSegments(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(segments) != 1 || logger.WriterStats().Rotations != 0 {
+		t.Errorf("expected no rotation with the default rotate size, got %d segments", len(segments))
+	}
+}
+
+func TestExternalRotation(t *testing.T) {
+	dir := t.TempDir()
+	fileName := filepath.Join(dir, "app.log")
+	logger := new(FileWriter)
+	logger.SetFileBaseName(fileName)
+	logger.SetReopenCheckInterval(time.Nanosecond)
+	defer logger.Close()
+	writeLine := func(content string) {
+		logger.WriteLog(&LogMsg{msgLevel: INFO, msgTime: time.Now(), msgContent: content})
+	}
+	readFile := func(path string) string {
+		content, _ := ioutil.ReadFile(path)
+		return string(content)
+	}
+
+	//logrotate移走文件后应写入新创建的文件
+	writeLine("before move")
+	os.Rename(fileName, fileName+".1")
+	writeLine("after move")
+	if !strings.Contains(readFile(fileName+".1"), "before move") || !strings.Contains(readFile(fileName), "after move") {
+		t.Errorf("moved file should be detected")
+	}
+
+	//copytruncate方式截断文件后应重新打开
+	os.Truncate(fileName, 0)
+	writeLine("after truncate")
+	if content := readFile(fileName); strings.Contains(content, "after move") || !strings.Contains(content, "after truncate") {
+		t.Errorf("truncated file should be detected: %q", content)
+	}
+
+	//关闭自动检查后，通过ReopenAllFiles（信号处理）重新打开
+	logger.SetReopenCheckInterval(time.Hour)
+	os.Rename(fileName, fileName+".2")
+	ReopenAllFiles()
+	writeLine("after reopen")
+	if !strings.Contains(readFile(fileName), "after reopen") || strings.Contains(readFile(fileName+".2"), "after reopen") {
+		t.Errorf("ReopenAllFiles should reopen the file")
+	}
+	stop := HandleReopenSignals()
+	stop()
+	stop()
+}
+
+func TestDegradedFileWriter(t *testing.T) {
+	if _, err := os.Stat("/dev/full"); err != nil {
+		t.Skip("/dev/full is not available")
+	}
+	//向/dev/full写入总是返回ENOSPC，模拟磁盘已满
+	logger := new(FileWriter)
+	logger.SetFileBaseName("/dev/full")
+	fallback := new(captureWriter)
+	logger.SetFallbackWriter(fallback)
+	for i := 0; i < 5; i++ {
+		err := logger.WriteLog(&LogMsg{msgLevel: INFO, msgTime: time.Now(), msgContent: "msg-" + strconv.Itoa(i)})
+		if i == 0 && !isPersistentWriteError(err) || i > 0 && err != ErrWriterDegraded {
+			t.Errorf("unexpected write error: %v", err)
+		}
+	}
+	stats := logger.WriterStats()
+	if !stats.Degraded || stats.Errors != 1 || len(fallback.messages()) != 5 {
+		t.Fatalf("writer should degrade after the first failure and skip the file until retry: %+v, %d", stats, len(fallback.messages()))
+	}
+
+	//重试失败时间隔加倍
+	logger.nextRetryTime = time.Now()
+	logger.WriteLog(&LogMsg{msgLevel: INFO, msgTime: time.Now(), msgContent: "retry"})
+	if logger.retryInterval != 2*minDegradedRetryInterval || logger.WriterStats().Errors != 2 {
+		t.Errorf("retry interval should back off: %s", logger.retryInterval)
+	}
+
+	//磁盘恢复后重试成功，退出降级状态
+	fileName := filepath.Join(t.TempDir(), "app.log")
+	logger.SetFileBaseName(fileName)
+	logger.nextRetryTime = time.Now()
+	logger.WriteLog(&LogMsg{msgLevel: INFO, msgTime: time.Now(), msgContent: "recovered"})
+	logger.Close()
+	content, _ := ioutil.ReadFile(fileName)
+	if logger.IsDegraded() || !strings.Contains(string(content), "recovered") || len(fallback.messages()) != 6 {
+		t.Errorf("writer should recover: %q", content)
+	}
+	for _, errno := range append(persistentWriteErrnos, os.ErrPermission) {
+		if !isPersistentWriteError(&os.PathError{Op: "open", Path: fileName, Err: errno}) {
+			t.Errorf("%s should be a persistent error", errno)
+		}
+	}
+	if isPersistentWriteError(io.ErrShortWrite) {
+		t.Error("unexpected persistent error classification")
+	}
+}
@@ -0,0 +1,263 @@
+package loglet
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+/**
+ * 内存环形缓冲书写器的可选配置
+ */
+type RingWriterOptions struct {
+	MaxMessages     int            //最多保留的日志条数，默认1000
+	MaxBytes        int64          //最多保留的日志字节数（按日志内容估算），0表示不限制
+	LevelCapacities map[string]int //按级别单独指定保留条数，指定了的级别使用独立的缓冲区，不会被其他级别的日志挤出
+}
+
+/**
+ * 内存环形缓冲书写器定义，只在内存中保留最近的日志，便于管理接口在不访问磁盘的情况下展示近期日志
+ * 每个缓冲区单独加锁，写入时只锁定日志所属级别的缓冲区，且临界区内只做常数时间的操作
+ */
+type RingWriter struct {
+	sequence      uint64                         //全局写入序号，用于合并多个缓冲区时恢复写入顺序
+	dropped       uint64                         //因订阅者消费过慢而丢弃的推送总数
+	defaultRing   *logRing                       //未单独指定容量的级别共用的缓冲区
+	levelRings    map[string]*logRing            //按级别独立的缓冲区（创建后只读，无需加锁）
+	subscribeLock sync.RWMutex                   //写入时只加读锁，订阅、取消订阅时加写锁
+	subscribers   map[*RingSubscription]struct{} //实时订阅新日志的订阅者
+}
+
+/**
+ * 创建一个内存环形缓冲书写器，opts可以为nil
+ */
+func NewRingWriter(opts *RingWriterOptions) *RingWriter {
+	maxMessages, maxBytes := 1000, int64(0)
+	var levelCapacities map[string]int
+	if opts != nil {
+		if opts.MaxMessages > 0 {
+			maxMessages = opts.MaxMessages
+		}
+		maxBytes = opts.MaxBytes
+		levelCapacities = opts.LevelCapacities
+	}
+	logger := &RingWriter{defaultRing: newLogRing(maxMessages, maxBytes), levelRings: make(map[string]*logRing)}
+	//级别名称统一转为大写，与日志的级别一致
+	for level, capacity := range levelCapacities {
+		if capacity > 0 {
+			logger.levelRings[strings.ToUpper(level)] = newLogRing(capacity, maxBytes)
+		}
+	}
+	return logger
+}
+
+/**
+ * 将日志保存到对应级别的缓冲区
+ */
+func (logger *RingWriter) WriteLog(msg *LogMsg) error {
+	ring, ok := logger.levelRings[msg.msgLevel]
+	if !ok {
+		ring = logger.defaultRing
+	}
+	ring.push(atomic.AddUint64(&logger.sequence, 1), msg)
+	logger.publish(msg)
+	return nil
+}
+
+/**
+ * 订阅之后写入的日志，bufferSize为订阅者的缓冲条数；订阅者消费过慢时新日志会被丢弃，不会阻塞写入
+ */
+func (logger *RingWriter) Subscribe(bufferSize int) *RingSubscription {
+	if bufferSize <= 0 {
+		bufferSize = 256
+	}
+	subscription := &RingSubscription{msgChan: make(chan *LogMsg, bufferSize), writer: logger}
+	logger.subscribeLock.Lock()
+	defer logger.subscribeLock.Unlock()
+	if logger.subscribers == nil {
+		logger.subscribers = make(map[*RingSubscription]struct{})
+	}
+	logger.subscribers[subscription] = struct{}{}
+	return subscription
+}
+
+/**
+ * 将日志推送给所有订阅者，订阅者缓冲已满时丢弃
+ */
+func (logger *RingWriter) publish(msg *LogMsg) {
+	logger.subscribeLock.RLock()
+	defer logger.subscribeLock.RUnlock()
+	for subscription := range logger.subscribers {
+		select {
+		case subscription.msgChan <- msg:
+		default:
+			atomic.AddUint64(&subscription.dropped, 1)
+			atomic.AddUint64(&logger.dropped, 1)
+		}
+	}
+}
+
+/**
+ * 获取内存环形缓冲书写器的统计信息（丢弃数为实时订阅中丢弃的推送数）
+ */
+func (logger *RingWriter) WriterStats() WriterStats {
+	return WriterStats{Drops: atomic.LoadUint64(&logger.dropped)}
+}
+
+/**
+ * 获取缓冲区中的全部日志，按写入顺序排列
+ */
+func (logger *RingWriter) Snapshot() []*LogMsg {
+	entries := logger.defaultRing.entries(nil)
+	for _, ring := range logger.levelRings {
+		entries = ring.entries(entries)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].sequence < entries[j].sequence
+	})
+	msgs := make([]*LogMsg, len(entries))
+	for i, entry := range entries {
+		msgs[i] = entry.msg
+	}
+	return msgs
+}
+
+/**
+ * 获取指定时间（含）之后产生的日志，按写入顺序排列
+ */
+func (logger *RingWriter) Since(since time.Time) []*LogMsg {
+	msgs := logger.Snapshot()
+	result := msgs[:0]
+	for _, msg := range msgs {
+		if !msg.msgTime.Before(since) {
+			result = append(result, msg)
+		}
+	}
+	return result
+}
+
+/**
+ * 关闭内存环形缓冲书写器（保留已缓存的日志，便于关闭后仍可查看）
+ */
+func (logger *RingWriter) Close() error {
+	return nil
+}
+
+/**
+ * 内存环形缓冲书写器的实时订阅
+ */
+type RingSubscription struct {
+	dropped   uint64 //因订阅者消费过慢而丢弃的日志条数
+	msgChan   chan *LogMsg
+	writer    *RingWriter
+	closeOnce sync.Once
+}
+
+/**
+ * 获取接收新日志的管道，订阅关闭后管道随之关闭
+ */
+func (subscription *RingSubscription) Messages() <-chan *LogMsg {
+	return subscription.msgChan
+}
+
+/**
+ * 获取因消费过慢而丢弃的日志条数
+ */
+func (subscription *RingSubscription) Dropped() uint64 {
+	return atomic.LoadUint64(&subscription.dropped)
+}
+
+/**
+ * 取消订阅
+ */
+func (subscription *RingSubscription) Close() {
+	subscription.closeOnce.Do(func() {
+		writer := subscription.writer
+		writer.subscribeLock.Lock()
+		delete(writer.subscribers, subscription)
+		writer.subscribeLock.Unlock()
+		//已从订阅者中移除，不会再有推送，可以安全关闭管道
+		close(subscription.msgChan)
+	})
+}
+
+/**
+ * 环形缓冲区中的一条日志
+ */
+type ringEntry struct {
+	sequence uint64
+	msg      *LogMsg
+	size     int64
+}
+
+/**
+ * 定长环形缓冲区，超出条数或字节数限制时淘汰最早的日志
+ */
+type logRing struct {
+	sync.Mutex
+	slots    []ringEntry
+	head     int //最早一条日志所在的位置
+	count    int
+	bytes    int64
+	maxBytes int64
+}
+
+/**
+ * 创建一个环形缓冲区
+ */
+func newLogRing(maxMessages int, maxBytes int64) *logRing {
+	return &logRing{slots: make([]ringEntry, maxMessages), maxBytes: maxBytes}
+}
+
+/**
+ * 追加一条日志
+ */
+func (ring *logRing) push(sequence uint64, msg *LogMsg) {
+	entry := ringEntry{sequence: sequence, msg: msg, size: getMsgSize(msg)}
+	ring.Lock()
+	defer ring.Unlock()
+	if ring.count == len(ring.slots) {
+		ring.popOldest()
+	}
+	for ring.maxBytes > 0 && ring.count > 0 && ring.bytes+entry.size > ring.maxBytes {
+		ring.popOldest()
+	}
+	ring.slots[(ring.head+ring.count)%len(ring.slots)] = entry
+	ring.count++
+	ring.bytes += entry.size
+}
+
+/**
+ * 淘汰最早的一条日志
+ */
+func (ring *logRing) popOldest() {
+	ring.bytes -= ring.slots[ring.head].size
+	ring.slots[ring.head] = ringEntry{}
+	ring.head = (ring.head + 1) % len(ring.slots)
+	ring.count--
+}
+
+/**
+ * 将缓冲区中的日志按先后顺序追加到result中
+ */
+func (ring *logRing) entries(result []ringEntry) []ringEntry {
+	ring.Lock()
+	defer ring.Unlock()
+	for i := 0; i < ring.count; i++ {
+		result = append(result, ring.slots[(ring.head+i)%len(ring.slots)])
+	}
+	return result
+}
+
+/**
+ * 估算一条日志占用的字节数（不做格式化，避免在写入路径上产生额外开销）
+ */
+func getMsgSize(msg *LogMsg) int64 {
+	size := len(textTimeLayout) + len(msg.targetPoint) + len(msg.msgLevel) + len(msg.msgContent) + 5
+	for _, field := range msg.fields {
+		size += len(field.Key) + 2 + 8
+	}
+	return int64(size)
+}
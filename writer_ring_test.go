@@ -0,0 +1,78 @@
+package loglet
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRingWriter(t *testing.T) {
+	writer := NewRingWriter(&RingWriterOptions{MaxMessages: 10, LevelCapacities: map[string]int{"error": 2}})
+	writer.WriteLog(&LogMsg{msgLevel: ERROR, msgTime: time.Now(), msgContent: "error-0"})
+	var wg sync.WaitGroup
+	for n := 0; n < 4; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				writer.WriteLog(&LogMsg{msgLevel: DEBUG, msgTime: time.Now(), msgContent: "debug-" + strconv.Itoa(i)})
+			}
+		}()
+	}
+	wg.Wait()
+	since := time.Now()
+	writer.WriteLog(&LogMsg{msgLevel: ERROR, msgTime: since, msgContent: "error-1"})
+
+	msgs := writer.Snapshot()
+	if len(msgs) != 12 {
+		t.Fatalf("expected 12 messages, got %d", len(msgs))
+	}
+	if msgs[0].msgContent != "error-0" || msgs[11].msgContent != "error-1" {
+		t.Errorf("errors should not be evicted by debug noise: %s ... %s", msgs[0].msgContent, msgs[11].msgContent)
+	}
+	recent := writer.Since(since)
+	if len(recent) != 1 || recent[0].msgContent != "error-1" {
+		t.Errorf("unexpected messages since %s: %d", since, len(recent))
+	}
+
+	writer = NewRingWriter(&RingWriterOptions{MaxBytes: 3 * getMsgSize(&LogMsg{msgLevel: INFO, msgContent: "msg-0"})})
+	for i := 0; i < 5; i++ {
+		writer.WriteLog(&LogMsg{msgLevel: INFO, msgContent: "msg-" + strconv.Itoa(i)})
+	}
+	msgs = writer.Snapshot()
+	if len(msgs) != 3 || msgs[0].msgContent != "msg-2" {
+		t.Errorf("unexpected messages with byte limit: %d", len(msgs))
+	}
+}
+
+func TestWriterLevel(t *testing.T) {
+	logger := NewLogger()
+	ring := NewRingWriter(nil)
+	capture := new(captureWriter)
+	logger.RegisterWriter("ring", ring)
+	logger.RegisterWriter("capture", capture)
+	logger.SetWriterLevel("capture", "warn")
+	logger.Debug("debug")
+	logger.Warn("warn")
+	if len(ring.Snapshot()) != 2 || len(capture.messages()) != 1 {
+		t.Errorf("writer level not applied: ring=%d capture=%d", len(ring.Snapshot()), len(capture.messages()))
+	}
+}
+
+func TestWriterLevelBelowLogLevel(t *testing.T) {
+	logger := NewLogger()
+	logger.CloseWriters()
+	ring := NewRingWriter(nil)
+	capture := new(captureWriter)
+	logger.RegisterWriter("ring", ring)
+	logger.RegisterWriter("capture", capture)
+	logger.SetLogLevel("info")
+	logger.SetWriterLevel("ring", "debug")
+	logger.Debug("debug")
+	logger.Info("info")
+	//内存缓冲的级别低于全局级别时仍能收到DEBUG日志，未单独设置级别的书写器不受影响
+	if len(ring.Snapshot()) != 2 || len(capture.messages()) != 1 {
+		t.Errorf("writer level should be independent of log level: ring=%d capture=%d", len(ring.Snapshot()), len(capture.messages()))
+	}
+}
@@ -0,0 +1,90 @@
+package loglet
+
+import (
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+/**
+ * 流式日志书写器的可选配置
+ */
+type StreamWriterOptions struct {
+	Formatter    Formatter            //日志格式化器，默认使用TextFormatter
+	LevelWriters map[string]io.Writer //按日志级别指定输出目标（例如ERROR、FATAL输出到os.Stderr），未指定的级别输出到默认目标
+	Synchronized bool                 //是否对写入加锁，避免并发写入时不同日志的内容相互交错
+}
+
+/**
+ * 流式日志书写器定义，将格式化后的日志写入任意io.Writer（bytes.Buffer、管道、net.Conn等）
+ */
+type StreamWriter struct {
+	writerCounters //写入字节数、错误等统计
+	writer         io.Writer
+	levelWriters   map[string]io.Writer
+	formatter      Formatter
+	writeLock      *sync.Mutex //未开启同步时为nil
+}
+
+/**
+ * 创建一个向指定io.Writer输出日志的书写器，opts可以为nil
+ */
+func NewStreamWriter(w io.Writer, opts *StreamWriterOptions) *StreamWriter {
+	logger := &StreamWriter{writer: w, formatter: new(TextFormatter)}
+	if opts == nil {
+		return logger
+	}
+	if opts.Formatter != nil {
+		logger.formatter = opts.Formatter
+	}
+	if len(opts.LevelWriters) > 0 {
+		logger.levelWriters = make(map[string]io.Writer, len(opts.LevelWriters))
+		//级别名称统一转为大写，与日志的级别一致
+		for level, levelWriter := range opts.LevelWriters {
+			logger.levelWriters[strings.ToUpper(level)] = levelWriter
+		}
+	}
+	if opts.Synchronized {
+		logger.writeLock = new(sync.Mutex)
+	}
+	return logger
+}
+
+/**
+ * 向目标输出日志，每条日志通过一次Write调用写出
+ */
+func (logger *StreamWriter) WriteLog(msg *LogMsg) error {
+	target := logger.writer
+	if levelWriter, ok := logger.levelWriters[msg.msgLevel]; ok {
+		target = levelWriter
+	}
+	if target == nil {
+		return nil
+	}
+	content := logger.formatter.Format(msg)
+	if logger.writeLock != nil {
+		logger.writeLock.Lock()
+		defer logger.writeLock.Unlock()
+	}
+	writeSize, err := target.Write(content)
+	atomic.AddUint64(&logger.bytes, uint64(writeSize))
+	if err != nil {
+		atomic.AddUint64(&logger.errors, 1)
+	}
+	return err
+}
+
+/**
+ * 获取流式日志书写器的统计信息
+ */
+func (logger *StreamWriter) WriterStats() WriterStats {
+	return logger.writerCounters.getStats()
+}
+
+/**
+ * 关闭流式日志书写器（输出目标由创建者负责关闭，这里不做处理）
+ */
+func (logger *StreamWriter) Close() error {
+	return nil
+}
@@ -0,0 +1,67 @@
+package loglet
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStreamWriter(t *testing.T) {
+	var outBuf, errBuf bytes.Buffer
+	writer := NewStreamWriter(&outBuf, &StreamWriterOptions{
+		LevelWriters: map[string]io.Writer{"error": &errBuf, FATAL: &errBuf},
+		Synchronized: true,
+	})
+	var wg sync.WaitGroup
+	for n := 0; n < 10; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				writer.WriteLog(&LogMsg{msgLevel: INFO, msgTime: time.Now(), targetPoint: getLoggingPoint(0), msgContent: line})
+				writer.WriteLog(&LogMsg{msgLevel: ERROR, msgTime: time.Now(), targetPoint: getLoggingPoint(0), msgContent: line})
+			}
+		}()
+	}
+	wg.Wait()
+	for _, buf := range []*bytes.Buffer{&outBuf, &errBuf} {
+		lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+		if len(lines) != 1000 {
+			t.Fatalf("expected 1000 lines, got %d", len(lines))
+		}
+		for _, logLine := range lines {
+			if !strings.HasSuffix(logLine, line) {
+				t.Fatalf("interleaved line: %s", logLine)
+			}
+		}
+	}
+	if strings.Contains(outBuf.String(), "[ERROR]") || strings.Contains(errBuf.String(), "[INFO]") {
+		t.Error("messages should be routed by level")
+	}
+}
+
+func TestJSONFormatter(t *testing.T) {
+	msgTime := time.Date(2024, 1, 2, 3, 4, 5, 6000000, time.UTC)
+	msg := &LogMsg{msgLevel: WARN, msgTime: msgTime, targetPoint: "a.go 1 main.main() [1]", msgContent: "<html> & 100%",
+		fields: []Field{{Key: "err", Value: errors.New("boom")}, {Key: "n", Value: 3}, {Key: "fn", Value: func() {}}}}
+	content := new(JSONFormatter).Format(msg)
+	if !bytes.HasSuffix(content, []byte("\n")) {
+		t.Error("json line should end with newline")
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(content, &decoded); err != nil {
+		t.Fatalf("invalid json %s: %v", content, err)
+	}
+	if decoded["time"] != "2024-01-02T03:04:05.006Z" || decoded["level"] != WARN || decoded["msg"] != "<html> & 100%" || decoded["caller"] != msg.targetPoint {
+		t.Errorf("unexpected json: %s", content)
+	}
+	fields := decoded["fields"].(map[string]interface{})
+	if fields["err"] != "boom" || fields["n"] != float64(3) {
+		t.Errorf("unexpected fields: %v", fields)
+	}
+}
@@ -0,0 +1,126 @@
+package loglet
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestConsoleColor(t *testing.T) {
+	msg := &LogMsg{msgLevel: ERROR, msgTime: time.Now(), targetPoint: "a.go 1 main.main() [1]", msgContent: "100% done", fields: []Field{{Key: "k", Value: "v"}}}
+	writer := NewConsoleWriter(COLOR_NEVER)
+	if writer.formatMsg(msg, writer.stderrColor) != msg.getFormattedMsg() {
+		t.Error("plain console output should be the same as file output")
+	}
+	writer = NewConsoleWriter(COLOR_ALWAYS)
+	colored := writer.formatMsg(msg, writer.stderrColor)
+	if !strings.Contains(colored, ansiRed+"[ERROR]"+ansiReset) || !strings.Contains(colored, ansiCyan+"k"+ansiReset+"=v") {
+		t.Errorf("unexpected colored output: %q", colored)
+	}
+
+	//t.Setenv在测试结束时恢复环境变量，避免影响其他测试
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("FORCE_COLOR", "1")
+	if !NewConsoleWriter(COLOR_AUTO).stdoutColor {
+		t.Error("FORCE_COLOR should enable color")
+	}
+	t.Setenv("NO_COLOR", "1")
+	if NewConsoleWriter(COLOR_AUTO).stdoutColor {
+		t.Error("NO_COLOR should disable color")
+	}
+}
+
+func TestWriterErrorHandler(t *testing.T) {
+	var lock sync.Mutex
+	var reported []*WriterError
+	logger := NewLogger()
+	logger.CloseWriters()
+	logger.SetErrorHandler(func(err *WriterError) {
+		lock.Lock()
+		defer lock.Unlock()
+		reported = append(reported, err)
+	})
+	errBroken := errors.New("broken pipe")
+	logger.RegisterWriter("capture", &captureWriter{err: errBroken})
+	async := NewAsyncWriter(&captureWriter{err: errBroken}, nil)
+	logger.RegisterWriter("async", async)
+	logger.Info("hello")
+	async.Flush()
+
+	lock.Lock()
+	if len(reported) != 2 {
+		t.Fatalf("expected 2 errors, got %d", len(reported))
+	}
+	writers := map[string]bool{}
+	for _, err := range reported {
+		if err.Op != "write" || !errors.Is(err, errBroken) {
+			t.Errorf("unexpected error: %s", err)
+		}
+		writers[err.Writer] = true
+	}
+	lock.Unlock()
+	if !writers["capture"] || !writers["async"] {
+		t.Errorf("errors should carry the writer name: %v", writers)
+	}
+	if async.WriteLog(&LogMsg{msgLevel: INFO}) != nil {
+		t.Error("async writer should not return errors of the inner writer")
+	}
+	logger.CloseWriters()
+	if async.WriteLog(&LogMsg{msgLevel: INFO}) != ErrWriterClosed {
+		t.Error("writing to a closed async writer should return ErrWriterClosed")
+	}
+
+	//文件书写器自行报告错误（限流、降级），日志记录器不重复报告
+	if _, err := os.Stat("/dev/full"); err != nil {
+		return
+	}
+	lock.Lock()
+	reported = nil
+	lock.Unlock()
+	fileWriter := new(FileWriter)
+	fileWriter.SetFileBaseName("/dev/full")
+	logger.RegisterWriter("file", fileWriter)
+	logger.Info("disk full")
+	logger.Info("still full")
+	lock.Lock()
+	defer lock.Unlock()
+	if len(reported) != 1 || reported[0].Op != "write" || !isPersistentWriteError(reported[0]) || !strings.Contains(reported[0].Error(), "degraded") {
+		t.Fatalf("unexpected file writer errors: %v", reported)
+	}
+	if reported[0].Writer != "file" || reported[0].Error() != "log writer file write error: "+reported[0].Err.Error() {
+		t.Errorf("unexpected error description: %s", reported[0])
+	}
+}
+
+func TestSetErrorHandlerWhileLogging(t *testing.T) {
+	logger := NewLogger()
+	logger.CloseWriters()
+	failing := &captureWriter{err: errors.New("broken pipe")}
+	logger.RegisterWriter("async", NewAsyncWriter(failing, nil))
+	logger.RegisterWriter("capture", &captureWriter{err: errors.New("broken pipe")})
+	//后台协程报告错误的同时替换错误处理函数，在-race下不应出现数据竞争
+	var count int64
+	handler := func(err *WriterError) {
+		atomic.AddInt64(&count, 1)
+	}
+	logger.SetErrorHandler(handler)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			logger.Info("message")
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		logger.SetErrorHandler(handler)
+	}
+	<-done
+	logger.CloseWriters()
+	if atomic.LoadInt64(&count) != 200 {
+		t.Errorf("every error should be reported: %d", atomic.LoadInt64(&count))
+	}
+}